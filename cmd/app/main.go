@@ -2,20 +2,45 @@ package main
 
 import (
 	"HDU-Auto-Word-Ans-Online-Backend/internal/api"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/apiauth"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/auth"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/client"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/config"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/middleware"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/prompt"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/router"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/tracing"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/version"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func main() {
+	checkConfig := flag.Bool("check-config", false, "校验配置并打印生效的配置 (密钥已遮盖)，然后退出")
+	flag.Parse()
+
+	logger := logging.New(logging.Config{})
+
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath("./config")
@@ -25,48 +50,174 @@ func main() {
 	viper.AutomaticEnv()
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Println("警告：未找到 config.yaml 文件，将完全依赖环境变量进行配置。")
+			logger.Warn("未找到 config.yaml 文件，将完全依赖环境变量进行配置")
 		} else {
-			log.Fatalf("读取配置文件失败: %s", err)
+			fatal(logger, "读取配置文件失败", err)
 		}
 	}
-	jsonPath := viper.GetString("database.json_path")
-	if _, err := os.Stat(jsonPath); os.IsNotExist(err) {
-		log.Fatalf("基础题库文件不存在: %s. 请确保它被正确打包到镜像或位于工作目录。", jsonPath)
+
+	cfg, err := config.Load(viper.GetViper())
+	if err != nil {
+		fatal(logger, "加载配置失败", err)
+	}
+	if *checkConfig {
+		os.Exit(runCheckConfig(cfg))
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal(logger, "配置校验失败，请检查 config.yaml 或对应的 HDU_APP_ 环境变量", err)
+	}
+
+	logger = logging.New(cfg.Logging)
+	slog.SetDefault(logger)
+	if used := viper.ConfigFileUsed(); used != "" {
+		logger.Info("已加载配置文件", "path", used)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, logger)
+	if err != nil {
+		fatal(logger, "初始化链路追踪失败", err)
 	}
-	wordRepo, err := repository.NewWordRepository(jsonPath)
+
+	wordRepo, err := repository.NewWordRepository(cfg.Database.JSONPath, logger)
 	if err != nil {
-		log.Fatalf("初始化题库失败: %s", err)
+		fatal(logger, "初始化题库失败", err)
 	}
 
-	answerBankPath := viper.GetString("database.answer_bank_path")
-	answerBankRepo, err := repository.NewAnswerBankRepository(answerBankPath)
+	answerBankRepo, err := repository.NewAnswerBankRepository(cfg.Database.AnswerBankPath, cfg.Database.AnswerBankStatsPath, logger)
 	if err != nil {
-		log.Fatalf("初始化答案银行失败: %s", err)
+		fatal(logger, "初始化答案银行失败", err)
 	}
 
-	hduClient := client.NewHduApiClient(viper.GetString("hdu_api.base_url"), viper.GetInt("hdu_api.timeout_seconds"))
+	prompts, err := prompt.NewStore(cfg.AIService.PromptsDir, logger)
+	if err != nil {
+		fatal(logger, "加载提示词模板失败", err)
+	}
+	if err := prompts.Watch(); err != nil {
+		logger.Warn("无法监听提示词目录，模板修改后需重启生效", "error", err)
+	}
+
+	hduClient := client.NewHduApiClient(cfg.HduAPI.BaseURL, cfg.HduAPI.TimeoutSeconds, cfg.HduAPI.Retry, logger)
 	aiService := service.NewAIService(
-		viper.GetString("ai_service.base_url"),
-		viper.GetString("ai_service.api_key"),
-		viper.GetString("ai_service.model"),
-		viper.GetInt("ai_service.timeout_seconds"),
+		cfg.AIService.BaseURL,
+		cfg.AIService.APIKey,
+		cfg.AIService.Model,
+		cfg.AIService.TimeoutSeconds,
+		prompts,
+		wordRepo,
+		logger,
 	)
+	aiService.SetVoting(cfg.AIService.Voting.Providers, cfg.AIService.Voting.ExamTypes)
+
+	authService, err := auth.NewAuthService(logger)
+	if err != nil {
+		fatal(logger, "初始化认证服务失败", err)
+	}
+
+	usageRepo, err := repository.NewUsageRepository(cfg.Database.UsagePath, logger)
+	if err != nil {
+		fatal(logger, "初始化AI用量记录失败", err)
+	}
+	usageService := service.NewUsageService(usageRepo, cfg.AIService.Budget, cfg.AIService.Pricing, logger)
+
+	paperRepo, err := repository.NewPaperRepository(cfg.Database.PapersPath, logger)
+	if err != nil {
+		fatal(logger, "初始化试卷记录失败", err)
+	}
+	historyRepo, err := repository.NewHistoryRepository(cfg.Database.HistoryPath, logger)
+	if err != nil {
+		fatal(logger, "初始化测试记录失败", err)
+	}
+	examService := service.NewExamService(hduClient, aiService, wordRepo, answerBankRepo, paperRepo, historyRepo, usageService, logger)
+
+	sessionStore, err := session.NewStore(cfg.Session)
+	if err != nil {
+		fatal(logger, "初始化会话存储失败", err)
+	}
+	sessions := session.NewManager(sessionStore, time.Duration(cfg.Session.TTLHours)*time.Hour, logger)
+
+	examHandler := api.NewExamHandler(examService, authService, sessions)
+	authHandler := api.NewAuthHandler(authService, examService, sessions)
 
-	authService, err := auth.NewAuthService()
+	studyRepo, err := repository.NewStudyRepository(cfg.Database.StudyPath, logger)
 	if err != nil {
-		log.Fatalf("初始化认证服务失败: %s", err)
+		fatal(logger, "初始化学习卡组失败", err)
 	}
+	studyHandler := api.NewStudyHandler(service.NewStudyService(hduClient, wordRepo, answerBankRepo, paperRepo, studyRepo, logger), sessions)
+	quizService := service.NewQuizService(wordRepo)
+	quizHandler := api.NewQuizHandler(quizService)
+	practiceRepo, err := repository.NewPracticeRepository(cfg.Database.PracticePath, logger)
+	if err != nil {
+		fatal(logger, "初始化练习记录失败", err)
+	}
+	practiceHandler := api.NewPracticeHandler(service.NewPracticeService(quizService, wordRepo, practiceRepo, logger))
+	notebookHandler := api.NewNotebookHandler(service.NewNotebookService(wordRepo, paperRepo))
+	historyHandler := api.NewHistoryHandler(service.NewHistoryService(historyRepo))
+	paperHandler := api.NewPaperHandler(service.NewReviewService(hduClient, wordRepo, answerBankRepo), sessions)
+	wordHandler := api.NewWordHandler(wordRepo)
+	adminHandler := api.NewAdminHandler(usageService, wordRepo, answerBankRepo)
+	healthHandler := api.NewHealthHandler(service.NewHealthService(wordRepo, answerBankRepo, aiService, cfg.Health))
+
+	tracingService := ""
+	if cfg.Tracing.Enabled() {
+		tracingService = cfg.Tracing.Service()
+	}
+	origins := middleware.NewAllowedOrigins(cfg.CORS.AllowedOrigins)
+	rateLimiter := middleware.NewRateLimiter(cfg.Limits.GlobalRPS, cfg.Limits.GlobalBurst)
+	inFlight := middleware.NewInFlightGuard(cfg.Limits.PerUserInFlight)
+	r := router.SetupRouter(examHandler, authHandler, studyHandler, quizHandler, practiceHandler, notebookHandler, historyHandler, paperHandler, wordHandler, adminHandler, healthHandler, router.Options{
+		AllowedOrigins: origins,
+		AdminToken:     cfg.Admin.Token,
+		Auth:           apiauth.NewAuthenticator(cfg.Auth),
+		Quota:          apiauth.NewQuotaTracker(),
+		RateLimiter:    rateLimiter,
+		InFlight:       inFlight,
+		Sessions:       sessions,
+		MetricsEnabled: cfg.Metrics.Enabled,
+		TracingService: tracingService,
+		Logger:         logger,
+	})
 
-	examService := service.NewExamService(hduClient, aiService, wordRepo, answerBankRepo)
+	watchConfig(logger, cfg, reloadable{hduClient: hduClient, aiService: aiService, prompts: prompts, origins: origins, rateLimiter: rateLimiter, inFlight: inFlight})
 
-	examHandler := api.NewExamHandler(examService, authService)
+	srv := &http.Server{Addr: cfg.Server.Port, Handler: r}
+	go func() {
+		logger.Info("服务启动", "address", "http://localhost"+cfg.Server.Port, "version", version.Version, "auth", cfg.Auth.Mode)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal(logger, "服务启动失败", err)
+		}
+	}()
 
-	r := router.SetupRouter(examHandler, viper.GetStringSlice("cors.allowed_origins"))
+	// 收到退出信号后停止接收新请求，并在退出前刷新尚未导出的 span
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	<-stop.Done()
+	logger.Info("正在关闭服务")
 
-	serverPort := viper.GetString("server.port")
-	fmt.Printf("服务启动于 http://localhost%s\n", serverPort)
-	if err := r.Run(serverPort); err != nil {
-		log.Fatalf("服务启动失败: %s", err)
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("关闭HTTP服务失败", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn("刷新链路追踪数据失败", "error", err)
+	}
+}
+
+// runCheckConfig 打印生效的配置和校验结果，返回进程退出码。
+func runCheckConfig(cfg *config.Config) int {
+	if used := viper.ConfigFileUsed(); used != "" {
+		fmt.Printf("# 配置文件: %s\n", used)
+	} else {
+		fmt.Println("# 未找到配置文件，仅使用默认值和环境变量")
+	}
+	if err := config.Print(os.Stdout, viper.GetViper()); err != nil {
+		fmt.Fprintln(os.Stderr, "输出配置失败:", err)
+		return 1
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+	fmt.Fprintln(os.Stderr, "配置校验通过")
+	return 0
 }
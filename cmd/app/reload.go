@@ -0,0 +1,108 @@
+package main
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/client"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/config"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/middleware"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/prompt"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// reloadable 是可以在运行时更新配置的组件。
+type reloadable struct {
+	hduClient   *client.HduApiClient
+	aiService   *service.AIService
+	prompts     *prompt.Store
+	origins     *middleware.AllowedOrigins
+	rateLimiter *middleware.RateLimiter
+	inFlight    *middleware.InFlightGuard
+}
+
+// watchConfig 监听配置文件。AI 模型与地址、投票、提示词目录、CORS、限流、超时与重试的修改立即生效；
+// 其余配置项的修改只记录警告，需要重启。新配置校验失败时整体忽略，继续使用旧配置。
+func watchConfig(logger *slog.Logger, current *config.Config, r reloadable) {
+	if viper.ConfigFileUsed() == "" {
+		return
+	}
+	logger = logger.With("component", "config")
+	var mu sync.Mutex
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		next, err := config.Load(viper.GetViper())
+		if err != nil {
+			logger.Error("配置文件已修改但解析失败，继续使用旧配置", "error", err)
+			return
+		}
+		if err := next.Validate(); err != nil {
+			logger.Error("配置文件已修改但校验失败，继续使用旧配置", "error", err)
+			return
+		}
+		r.apply(logger, current, next)
+		current = next
+	})
+	viper.WatchConfig()
+	logger.Info("已开启配置文件热加载", "path", viper.ConfigFileUsed())
+}
+
+func (r reloadable) apply(logger *slog.Logger, old, next *config.Config) {
+	oldAI, nextAI := old.AIService, next.AIService
+	if oldAI.BaseURL != nextAI.BaseURL || oldAI.APIKey != nextAI.APIKey || oldAI.Model != nextAI.Model || oldAI.TimeoutSeconds != nextAI.TimeoutSeconds {
+		r.aiService.Reconfigure(service.AIProvider{BaseURL: nextAI.BaseURL, APIKey: nextAI.APIKey, Model: nextAI.Model}, nextAI.TimeoutSeconds)
+		logger.Info("AI服务配置已更新", "base_url", nextAI.BaseURL, "model", nextAI.Model, "timeout_seconds", nextAI.TimeoutSeconds)
+	}
+	if !reflect.DeepEqual(oldAI.Voting, nextAI.Voting) {
+		r.aiService.SetVoting(nextAI.Voting.Providers, nextAI.Voting.ExamTypes)
+		logger.Info("AI投票配置已更新", "providers", len(nextAI.Voting.Providers))
+	}
+	if oldAI.PromptsDir != nextAI.PromptsDir {
+		if err := r.prompts.SetDir(nextAI.PromptsDir); err != nil {
+			logger.Error("切换提示词目录失败，继续使用旧模板", "dir", nextAI.PromptsDir, "error", err)
+		}
+	}
+	if !reflect.DeepEqual(old.CORS, next.CORS) {
+		r.origins.Set(next.CORS.AllowedOrigins)
+		logger.Info("CORS 允许的来源已更新", "origins", next.CORS.AllowedOrigins)
+	}
+	if old.Limits != next.Limits {
+		r.rateLimiter.Set(next.Limits.GlobalRPS, next.Limits.GlobalBurst)
+		r.inFlight.SetEnabled(next.Limits.PerUserInFlight)
+		logger.Info("限流配置已更新", "global_rps", next.Limits.GlobalRPS, "global_burst", next.Limits.GlobalBurst, "per_user_in_flight", next.Limits.PerUserInFlight)
+	}
+	if old.HduAPI.TimeoutSeconds != next.HduAPI.TimeoutSeconds || !reflect.DeepEqual(old.HduAPI.Retry, next.HduAPI.Retry) {
+		r.hduClient.Reconfigure(next.HduAPI.TimeoutSeconds, next.HduAPI.Retry)
+		logger.Info("HDU接口超时与重试配置已更新", "timeout_seconds", next.HduAPI.TimeoutSeconds, "max_attempts", next.HduAPI.Retry.MaxAttempts)
+	}
+
+	var restartNeeded []string
+	for key, changed := range map[string]bool{
+		"server":             old.Server != next.Server,
+		"logging":            old.Logging != next.Logging,
+		"hdu_api.base_url":   old.HduAPI.BaseURL != next.HduAPI.BaseURL,
+		"ai_service.budget":  oldAI.Budget != nextAI.Budget,
+		"ai_service.pricing": oldAI.Pricing != nextAI.Pricing,
+		"database":           old.Database != next.Database,
+		"tracing":            old.Tracing != next.Tracing,
+		"health":             old.Health != next.Health,
+		"metrics":            old.Metrics != next.Metrics,
+		"admin":              old.Admin != next.Admin,
+		"auth":               !reflect.DeepEqual(old.Auth, next.Auth),
+		"session":            old.Session != next.Session,
+	} {
+		if changed {
+			restartNeeded = append(restartNeeded, key)
+		}
+	}
+	if len(restartNeeded) > 0 {
+		sort.Strings(restartNeeded)
+		logger.Warn("以下配置的修改需要重启服务才能生效", "keys", restartNeeded)
+	}
+}
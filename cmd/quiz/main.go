@@ -0,0 +1,65 @@
+// quiz 用 database.json 生成练习试卷并以 JSON 输出，格式与学校平台的试卷接口相同，
+// 可用于离线练习或作为模拟平台的试卷数据。
+package main
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/quiz"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+)
+
+func main() {
+	dbPath := flag.String("db", "./database.json", "题库文件路径")
+	count := flag.Int("count", 100, "题目数量")
+	mode := flag.String("mode", quiz.ModeMixed, "出题方式: en_zh, zh_en 或 mixed")
+	seed := flag.Uint64("seed", 0, "随机种子，0 表示随机")
+	withAnswers := flag.Bool("answers", false, "同时输出答案 (输出 {paper, key} 而不是试卷本身)")
+	output := flag.String("o", "", "输出文件，默认输出到标准输出")
+	flag.Parse()
+
+	if err := run(*dbPath, *count, *mode, *seed, *withAnswers, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "生成试卷失败:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dbPath string, count int, mode string, seed uint64, withAnswers bool, output string) error {
+	logger := logging.NewWithWriter(os.Stderr, logging.Config{Level: "warn"})
+	wordRepo, err := repository.NewWordRepository(dbPath, logger)
+	if err != nil {
+		return err
+	}
+	definitions, _ := wordRepo.Definitions()
+
+	opts := quiz.Options{Count: count, Mode: mode}
+	if seed != 0 {
+		opts.Rand = rand.New(rand.NewPCG(seed, seed))
+	}
+	generated, err := quiz.NewGenerator(definitions).Generate(opts)
+	if err != nil {
+		return err
+	}
+
+	var result any = generated.Paper
+	if withAnswers {
+		result = generated
+	}
+	out := os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
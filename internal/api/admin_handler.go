@@ -0,0 +1,121 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	usageService   *service.UsageService
+	wordRepo       *repository.WordRepository
+	answerBankRepo *repository.AnswerBankRepository
+}
+
+func NewAdminHandler(usageService *service.UsageService, wordRepo *repository.WordRepository, answerBankRepo *repository.AnswerBankRepository) *AdminHandler {
+	return &AdminHandler{usageService: usageService, wordRepo: wordRepo, answerBankRepo: answerBankRepo}
+}
+
+type UpdateAnswerRequest struct {
+	Answer string `json:"answer" binding:"required,oneof=A B C D"`
+}
+
+// AIUsageHandler 返回 AI 用量统计，days 参数控制按日明细的天数 (默认 7，最多 90)。
+func (h *AdminHandler) AIUsageHandler(c *gin.Context) {
+	days, ok := queryInt(c, "days", 7, 1, 90)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, h.usageService.Report(days))
+}
+
+// ReloadDictionaryHandler 重新读取 database.json 并原子替换题库，正在处理的测试不受影响。
+func (h *AdminHandler) ReloadDictionaryHandler(c *gin.Context) {
+	words, meanings, err := h.wordRepo.Reload()
+	if err != nil {
+		logging.FromContext(c.Request.Context(), slog.Default()).Error("重新加载题库失败", "error", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeDictionaryReloadFailed, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"words": words, "meanings": meanings})
+}
+
+// SearchAnswerBankHandler 按题目子串搜索答案银行，支持 offset/limit 分页。
+func (h *AdminHandler) SearchAnswerBankHandler(c *gin.Context) {
+	offset, ok := queryInt(c, "offset", 0, 0, math.MaxInt32)
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(c, "limit", 20, 1, 100)
+	if !ok {
+		return
+	}
+	entries, total := h.answerBankRepo.Search(c.Query("q"), offset, limit)
+	c.JSON(http.StatusOK, gin.H{"total": total, "offset": offset, "limit": limit, "entries": entries})
+}
+
+func (h *AdminHandler) GetAnswerHandler(c *gin.Context) {
+	entry, err := h.answerBankRepo.Get(c.Param("id"))
+	if err != nil {
+		h.handleAnswerBankError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// UpdateAnswerHandler 修正一条答案，请求体为 {"answer": "A"}。
+func (h *AdminHandler) UpdateAnswerHandler(c *gin.Context) {
+	var req UpdateAnswerRequest
+	if !bindJSON(c, &req, false) {
+		return
+	}
+	entry, err := h.answerBankRepo.Update(c.Param("id"), req.Answer)
+	if err != nil {
+		h.handleAnswerBankError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *AdminHandler) DeleteAnswerHandler(c *gin.Context) {
+	if err := h.answerBankRepo.Delete(c.Param("id")); err != nil {
+		h.handleAnswerBankError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RecentAnswersHandler 返回本次运行以来学习协程新增的答案。
+func (h *AdminHandler) RecentAnswersHandler(c *gin.Context) {
+	limit, ok := queryInt(c, "limit", 50, 1, 200)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"additions": h.answerBankRepo.Recent(limit)})
+}
+
+// AnswerBankStatsHandler 返回答案银行的规模和最近 days 天的增长。
+func (h *AdminHandler) AnswerBankStatsHandler(c *gin.Context) {
+	days, ok := queryInt(c, "days", 7, 1, 90)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, h.answerBankRepo.Stats(time.Now(), days))
+}
+
+func (h *AdminHandler) handleAnswerBankError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrAnswerNotFound) {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, c.Param("id"))
+		return
+	}
+	logging.FromContext(c.Request.Context(), slog.Default()).Error("答案银行操作失败", "error", err)
+	response.Error(c, http.StatusInternalServerError, response.CodeInternalError, err.Error())
+}
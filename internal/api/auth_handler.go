@@ -0,0 +1,98 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/auth"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/middleware"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// requireSession 返回本次请求的会话；请求未携带 X-Session-ID 时写入 401 响应。
+func requireSession(c *gin.Context) (*session.Session, bool) {
+	sess := middleware.CurrentSession(c)
+	if sess == nil {
+		response.Error(c, http.StatusUnauthorized, response.CodeSessionExpired, "缺少 X-Session-ID 请求头")
+		return nil, false
+	}
+	return sess, true
+}
+
+type AuthHandler struct {
+	authService *auth.AuthService
+	examService *service.ExamService
+	sessions    *session.Manager
+}
+
+func NewAuthHandler(authService *auth.AuthService, examService *service.ExamService, sessions *session.Manager) *AuthHandler {
+	return &AuthHandler{authService: authService, examService: examService, sessions: sessions}
+}
+
+// LoginHandler 通过 SSO 登录并创建会话，不会开始测试。会话 ID 同时通过 X-Session-ID 响应头返回。
+func (h *AuthHandler) LoginHandler(c *gin.Context) {
+	var req LoginRequest
+	if !bindJSON(c, &req, false) {
+		return
+	}
+
+	xAuthToken, err := h.authService.Login(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, response.CodeLoginFailed, err.Error())
+		return
+	}
+	sess, err := h.sessions.Create(req.Username, xAuthToken)
+	if err != nil {
+		logging.FromContext(c.Request.Context(), slog.Default()).Error("创建会话失败", "error", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, "创建会话失败")
+		return
+	}
+	c.Header(middleware.SessionHeader, sess.ID)
+	c.JSON(http.StatusOK, gin.H{"session_id": sess.ID, "username": sess.Username, "expires_at": sess.ExpiresAt})
+}
+
+// MeHandler 返回当前会话的信息，并通过获取当前周数确认上游令牌仍然有效。
+func (h *AuthHandler) MeHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+
+	week, err := h.examService.GetCurrentWeek(c.Request.Context(), sess.UpstreamToken)
+	if err != nil {
+		handleUpstreamError(c, h.sessions, err, "验证登录状态失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"username":     sess.Username,
+		"created_at":   sess.CreatedAt,
+		"expires_at":   sess.ExpiresAt,
+		"current_week": week,
+	})
+}
+
+// LogoutHandler 注销 X-Session-ID 对应的会话，服务端保存的上游令牌随之丢弃。
+// 注销是幂等的，未携带会话或会话已过期时同样返回 204。
+func (h *AuthHandler) LogoutHandler(c *gin.Context) {
+	id := c.GetHeader(middleware.SessionHeader)
+	if id == "" {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	if err := h.sessions.Delete(id); err != nil {
+		logging.FromContext(c.Request.Context(), slog.Default()).Error("注销会话失败", "error", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, nil)
+		return
+	}
+	logging.FromContext(c.Request.Context(), slog.Default()).Info("用户已注销")
+	c.Status(http.StatusNoContent)
+}
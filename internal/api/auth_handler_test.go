@@ -0,0 +1,133 @@
+package api_test
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/apiauth"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/middleware"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/router"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter 用真实的路由和中间件创建服务，只有认证相关的处理器可用。
+// ttl 为负数时创建出的会话立即过期。
+func newTestRouter(t *testing.T, ttl time.Duration) (*gin.Engine, *session.Manager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sessions := session.NewManager(session.NewMemoryStore(), ttl, logger)
+	authHandler := api.NewAuthHandler(nil, nil, sessions)
+	r := router.SetupRouter(nil, authHandler, nil, nil, nil, nil, nil, nil, nil, nil, nil, router.Options{
+		AllowedOrigins: middleware.NewAllowedOrigins(nil),
+		Auth:           apiauth.NewAuthenticator(apiauth.Config{}),
+		Quota:          apiauth.NewQuotaTracker(),
+		RateLimiter:    middleware.NewRateLimiter(0, 1),
+		InFlight:       middleware.NewInFlightGuard(true),
+		Sessions:       sessions,
+		Logger:         logger,
+	})
+	return r, sessions
+}
+
+func serve(r *gin.Engine, method, path, sessionID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set(middleware.SessionHeader, sessionID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("响应不是错误信封: %s", w.Body.String())
+	}
+	return body.Code
+}
+
+func TestLoginWithStaleSessionHeader(t *testing.T) {
+	r, sessions := newTestRouter(t, -time.Hour)
+	expired, err := sessions.Create("alice", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/api/v1/auth/login", "/api/v1/login-and-start"} {
+		for name, id := range map[string]string{"不存在的会话": "stale-session-id", "已过期的会话": expired.ID} {
+			t.Run(path+" "+name, func(t *testing.T) {
+				// 缺少密码时由登录处理器返回 400，说明请求没有被 Session 中间件以 401 拦下
+				w := serve(r, http.MethodPost, path, id, `{"username":"alice"}`)
+				if w.Code != http.StatusBadRequest {
+					t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+				}
+				if code := errorCode(t, w); code != response.CodeInvalidRequest {
+					t.Errorf("code = %s, want %s", code, response.CodeInvalidRequest)
+				}
+			})
+		}
+	}
+
+	// 其他接口仍然要求会话有效
+	w := serve(r, http.MethodGet, "/api/v1/auth/me", "stale-session-id", "")
+	if w.Code != http.StatusUnauthorized || errorCode(t, w) != response.CodeSessionExpired {
+		t.Errorf("/auth/me: status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLogoutWithExpiredSession(t *testing.T) {
+	r, sessions := newTestRouter(t, -time.Hour)
+	expired, err := sessions.Create("alice", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		sessionID string
+	}{
+		{name: "已过期的会话", sessionID: expired.ID},
+		{name: "不存在的会话", sessionID: "stale-session-id"},
+		{name: "未携带会话", sessionID: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodPost, "/api/v1/auth/logout", tt.sessionID, "")
+			if w.Code != http.StatusNoContent {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLogoutDeletesSession(t *testing.T) {
+	r, sessions := newTestRouter(t, time.Hour)
+	sess, err := sessions.Create("alice", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := serve(r, http.MethodPost, "/api/v1/auth/logout", sess.ID, ""); w.Code != http.StatusNoContent {
+			t.Fatalf("第 %d 次注销: status = %d, want %d", i+1, w.Code, http.StatusNoContent)
+		}
+	}
+	if _, err := sessions.Get(sess.ID); err == nil {
+		t.Error("注销后会话仍然有效")
+	}
+}
@@ -1,62 +1,65 @@
 package api
 
 import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/auth"
-	"HDU-Auto-Word-Ans-Online-Backend/internal/client"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/middleware"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
-	"errors"
-	"fmt"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"context"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// week 为 0 时自动获取当前周数；correct_count 省略时全部作答正确。
 type StartTestRequest struct {
-	Week               int  `json:"week"`
-	ExamType           int  `json:"exam_type"` // 0:自测 1:考试
-	SubmitDelaySeconds int  `json:"submit_delay_seconds"`
-	CorrectCount       *int `json:"correct_count"`
+	Week               int  `json:"week" binding:"min=0,max=30"`
+	ExamType           int  `json:"exam_type" binding:"oneof=0 1"` // 0:自测 1:考试
+	SubmitDelaySeconds int  `json:"submit_delay_seconds" binding:"min=0,max=3600"`
+	CorrectCount       *int `json:"correct_count" binding:"omitempty,min=0"`
 }
 
 type LoginAndStartRequest struct {
 	Username           string `json:"username" binding:"required"`
 	Password           string `json:"password" binding:"required"`
-	Week               int    `json:"week"`
-	ExamType           int    `json:"exam_type"` // 0:自测 1:考试
-	SubmitDelaySeconds int    `json:"submit_delay_seconds"`
-	CorrectCount       *int   `json:"correct_count"`
+	Week               int    `json:"week" binding:"min=0,max=30"`
+	ExamType           int    `json:"exam_type" binding:"oneof=0 1"` // 0:自测 1:考试
+	SubmitDelaySeconds int    `json:"submit_delay_seconds" binding:"min=0,max=3600"`
+	CorrectCount       *int   `json:"correct_count" binding:"omitempty,min=0"`
 }
 
 type ExamHandler struct {
 	examService *service.ExamService
 	authService *auth.AuthService
+	sessions    *session.Manager
 }
 
-func NewExamHandler(examService *service.ExamService, authService *auth.AuthService) *ExamHandler {
-	return &ExamHandler{examService: examService, authService: authService}
+func NewExamHandler(examService *service.ExamService, authService *auth.AuthService, sessions *session.Manager) *ExamHandler {
+	return &ExamHandler{examService: examService, authService: authService, sessions: sessions}
 }
 
-func (h *ExamHandler) handleProcessTestError(c *gin.Context, err error, contextMsg string) {
-	if errors.Is(err, client.ErrRateLimited) {
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": "请求频率过快，请稍后再试"})
-		return
-	}
-	c.JSON(http.StatusInternalServerError, gin.H{
-		"error":   contextMsg,
-		"details": err.Error(),
-	})
+// testContext 返回处理测试使用的 context：保留请求的日志字段，但不随客户端断开而取消，
+// 以免提交延迟期间关闭页面导致已领取的试卷无法提交。
+func testContext(c *gin.Context) context.Context {
+	return context.WithoutCancel(c.Request.Context())
 }
 
 func (h *ExamHandler) StartTestHandler(c *gin.Context) {
 	var req StartTestRequest
-	XAuthToken := c.GetHeader("X-Auth-Token")
+	XAuthToken := upstreamToken(c)
 
 	if XAuthToken == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "X-Auth-Token header is required"})
+		response.Error(c, http.StatusUnauthorized, response.CodeMissingAuthToken, nil)
 		return
 	}
 
-	_ = c.ShouldBindJSON(&req)
+	if !bindJSON(c, &req, true) {
+		return
+	}
+	ctx := testContext(c)
 
 	correctCount := -1
 	if req.CorrectCount != nil {
@@ -65,68 +68,69 @@ func (h *ExamHandler) StartTestHandler(c *gin.Context) {
 
 	week := req.Week
 	if week == 0 {
-		fmt.Println("用户未提供周数，正在自动获取当前周数...")
-		fetchedWeek, err := h.examService.GetCurrentWeek(XAuthToken)
+		fetchedWeek, err := h.examService.GetCurrentWeek(ctx, XAuthToken)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "自动获取当前周数失败",
-				"details": err.Error(),
-			})
+			handleUpstreamError(c, h.sessions, err, "自动获取当前周数失败")
 			return
 		}
 		week = fetchedWeek
-		fmt.Printf("自动获取成功，当前周数: %d\n", week)
+		logging.FromContext(ctx, slog.Default()).Info("用户未提供周数，已自动获取当前周数", "week", week)
 	}
 
-	result, err := h.examService.ProcessTest(XAuthToken, req.SubmitDelaySeconds, week, req.ExamType, correctCount)
+	username := ""
+	if sess := middleware.CurrentSession(c); sess != nil {
+		username = sess.Username
+	}
+	result, err := h.examService.ProcessTest(ctx, XAuthToken, username, req.SubmitDelaySeconds, week, req.ExamType, correctCount)
 
 	if err != nil {
-		h.handleProcessTestError(c, err, "处理测试失败")
+		handleUpstreamError(c, h.sessions, err, "处理测试失败")
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": result})
+	c.JSON(http.StatusOK, gin.H{"message": result.Message, "ai_confidence": result.AIConfidence})
 }
 
 func (h *ExamHandler) LoginAndStartTestHandler(c *gin.Context) {
 	var req LoginAndStartRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效: " + err.Error()})
+	if !bindJSON(c, &req, false) {
 		return
 	}
 
-	xAuthToken, err := h.authService.Login(req.Username, req.Password)
+	ctx := testContext(c)
+	xAuthToken, err := h.authService.Login(ctx, req.Username, req.Password)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, response.CodeLoginFailed, err.Error())
+		return
+	}
+	// 登录成功后立即建立会话，测试失败时客户端也能从响应头拿到会话 ID
+	sess, err := h.sessions.Create(req.Username, xAuthToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "SSO 登录失败",
-			"details": err.Error(),
-		})
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, "创建会话失败")
 		return
 	}
+	middleware.SetSession(c, sess)
+	c.Header(middleware.SessionHeader, sess.ID)
 
 	week := req.Week
 	if week == 0 {
-		fmt.Println("用户未提供周数，正在自动获取当前周数...")
-		fetchedWeek, err := h.examService.GetCurrentWeek(xAuthToken)
+		fetchedWeek, err := h.examService.GetCurrentWeek(ctx, xAuthToken)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "自动获取当前周数失败 (登录成功后)",
-				"details": err.Error(),
-			})
+			handleUpstreamError(c, h.sessions, err, "自动获取当前周数失败 (登录成功后)")
 			return
 		}
 		week = fetchedWeek
-		fmt.Printf("自动获取成功，当前周数: %d\n", week)
+		logging.FromContext(ctx, slog.Default()).Info("用户未提供周数，已自动获取当前周数", "week", week)
 	}
 	correctCount := -1
 	if req.CorrectCount != nil {
 		correctCount = *req.CorrectCount
 	}
 
-	result, err := h.examService.ProcessTest(xAuthToken, req.SubmitDelaySeconds, week, req.ExamType, correctCount)
+	result, err := h.examService.ProcessTest(ctx, xAuthToken, sess.Username, req.SubmitDelaySeconds, week, req.ExamType, correctCount)
 	if err != nil {
-		h.handleProcessTestError(c, err, "处理测试失败 (登录成功后)")
+		handleUpstreamError(c, h.sessions, err, "处理测试失败 (登录成功后)")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": result, "x_auth_token": xAuthToken})
+	c.JSON(http.StatusOK, gin.H{"message": result.Message, "ai_confidence": result.AIConfidence, "session_id": sess.ID, "expires_at": sess.ExpiresAt})
 }
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// LivenessHandler 用于存活探针，进程能响应即返回 200。
+func (h *HealthHandler) LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.healthService.Liveness())
+}
+
+// ReadinessHandler 用于就绪探针，任意依赖检查失败时返回 503。
+func (h *HealthHandler) ReadinessHandler(c *gin.Context) {
+	report := h.healthService.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != service.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HistoryHandler struct {
+	historyService *service.HistoryService
+}
+
+func NewHistoryHandler(historyService *service.HistoryService) *HistoryHandler {
+	return &HistoryHandler{historyService: historyService}
+}
+
+// ListHistoryHandler 返回用户通过会话完成的测试记录，可按 week 筛选，支持 offset/limit 分页。
+func (h *HistoryHandler) ListHistoryHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	week, ok := queryInt(c, "week", 0, 0, 30)
+	if !ok {
+		return
+	}
+	offset, ok := queryInt(c, "offset", 0, 0, math.MaxInt32)
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(c, "limit", 20, 1, 100)
+	if !ok {
+		return
+	}
+	records, total := h.historyService.List(sess.Username, week, offset, limit)
+	c.JSON(http.StatusOK, gin.H{"total": total, "offset": offset, "limit": limit, "records": records})
+}
+
+// StatsHandler 返回用户测试的总体统计和按周的得分变化。
+func (h *HistoryHandler) StatsHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, h.historyService.Stats(sess.Username))
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotebookHandler struct {
+	notebookService *service.NotebookService
+}
+
+func NewNotebookHandler(notebookService *service.NotebookService) *NotebookHandler {
+	return &NotebookHandler{notebookService: notebookService}
+}
+
+// MistakesHandler 返回用户在学校平台上答错的题目，可按 week 筛选，支持 offset/limit 分页。
+// 只包含通过会话开始、且提交后成功获取到详情的试卷。
+func (h *NotebookHandler) MistakesHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	week, ok := queryInt(c, "week", 0, 0, 30)
+	if !ok {
+		return
+	}
+	offset, ok := queryInt(c, "offset", 0, 0, math.MaxInt32)
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(c, "limit", 20, 1, 100)
+	if !ok {
+		return
+	}
+	mistakes, total := h.notebookService.Mistakes(sess.Username, week, offset, limit)
+	c.JSON(http.StatusOK, gin.H{"total": total, "offset": offset, "limit": limit, "mistakes": mistakes})
+}
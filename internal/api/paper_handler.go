@@ -0,0 +1,45 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaperHandler struct {
+	reviewService *service.ReviewService
+	sessions      *session.Manager
+}
+
+func NewPaperHandler(reviewService *service.ReviewService, sessions *session.Manager) *PaperHandler {
+	return &PaperHandler{reviewService: reviewService, sessions: sessions}
+}
+
+// ReviewPaperHandler 从学校平台获取用户做过的一套试卷，附带题库释义和答案银行收录情况。
+func (h *PaperHandler) ReviewPaperHandler(c *gin.Context) {
+	xAuthToken := upstreamToken(c)
+	if xAuthToken == "" {
+		response.Error(c, http.StatusUnauthorized, response.CodeMissingAuthToken, nil)
+		return
+	}
+	paperID := c.Param("paperId")
+	if !paperIDPattern.MatchString(paperID) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, []FieldError{{Field: "paperId", Rule: "paperid"}})
+		return
+	}
+
+	review, err := h.reviewService.ReviewPaper(c.Request.Context(), xAuthToken, paperID)
+	if errors.Is(err, service.ErrEmptyPaper) {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		handleUpstreamError(c, h.sessions, err, "获取试卷详情失败")
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/quiz"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// count 默认 20。练习试卷总是随机生成，不接受 seed，否则可以用 GET /quiz 以相同的种子得到答案。
+type StartPracticeRequest struct {
+	Count int    `json:"count" binding:"omitempty,min=1,max=100"`
+	Mode  string `json:"mode" binding:"omitempty,oneof=en_zh zh_en mixed"`
+}
+
+type PracticeHandler struct {
+	practiceService *service.PracticeService
+}
+
+func NewPracticeHandler(practiceService *service.PracticeService) *PracticeHandler {
+	return &PracticeHandler{practiceService: practiceService}
+}
+
+// StartPracticeHandler 用本地题库生成一套练习试卷，答案保存在服务端，提交后批改。
+func (h *PracticeHandler) StartPracticeHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	var req StartPracticeRequest
+	if !bindJSON(c, &req, true) {
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 20
+	}
+	if req.Mode == "" {
+		req.Mode = quiz.ModeMixed
+	}
+
+	paper, err := h.practiceService.Start(c.Request.Context(), sess.Username, req.Count, req.Mode)
+	if errors.Is(err, quiz.ErrNotEnoughWords) {
+		response.Error(c, http.StatusUnprocessableEntity, response.CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		logging.FromContext(c.Request.Context(), slog.Default()).Error("生成练习试卷失败", "error", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, nil)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"paper": paper})
+}
+
+// SubmitPracticeHandler 批改练习试卷，请求体与向学校平台交卷时相同 (model.SubmissionPayload)。
+func (h *PracticeHandler) SubmitPracticeHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	var payload model.SubmissionPayload
+	if !bindJSON(c, &payload, false) {
+		return
+	}
+	if payload.PaperID == "" {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, []FieldError{{Field: "paperId", Rule: "required"}})
+		return
+	}
+
+	result, err := h.practiceService.Submit(c.Request.Context(), sess.Username, payload)
+	if errors.Is(err, repository.ErrPracticeNotFound) {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		logging.FromContext(c.Request.Context(), slog.Default()).Error("批改练习试卷失败", "error", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, nil)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// MasteryHandler 返回用户的练习概况和最薄弱的单词，limit 默认 20。
+func (h *PracticeHandler) MasteryHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(c, "limit", 20, 1, 200)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, h.practiceService.Mastery(sess.Username, limit))
+}
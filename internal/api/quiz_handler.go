@@ -0,0 +1,52 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/quiz"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuizHandler struct {
+	quizService *service.QuizService
+}
+
+func NewQuizHandler(quizService *service.QuizService) *QuizHandler {
+	return &QuizHandler{quizService: quizService}
+}
+
+// GenerateQuizHandler 用本地题库生成练习试卷。参数: count (默认 20，最多 100)、
+// mode (en_zh / zh_en / mixed)、seed (可选，用于复现)、with_answers (是否附带答案)。
+func (h *QuizHandler) GenerateQuizHandler(c *gin.Context) {
+	count, ok := queryInt(c, "count", 20, 1, 100)
+	if !ok {
+		return
+	}
+	mode := c.DefaultQuery("mode", quiz.ModeMixed)
+	if mode != quiz.ModeEnToZh && mode != quiz.ModeZhToEn && mode != quiz.ModeMixed {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, "参数 mode 必须是 en_zh、zh_en 或 mixed")
+		return
+	}
+	seed, ok := querySeed(c)
+	if !ok {
+		return
+	}
+
+	generated, err := h.quizService.Generate(count, mode, seed)
+	if errors.Is(err, quiz.ErrNotEnoughWords) {
+		response.Error(c, http.StatusUnprocessableEntity, response.CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, err.Error())
+		return
+	}
+	if c.Query("with_answers") == "true" {
+		c.JSON(http.StatusOK, generated)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"paper": generated.Paper})
+}
@@ -0,0 +1,37 @@
+package response
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey 是请求 ID 在 gin.Context 中的键，由 middleware.RequestID 写入。
+const RequestIDKey = "request_id"
+
+// ErrorBody 是所有接口统一的错误响应格式。Code 为稳定的机器可读错误码，
+// Message 按 Accept-Language 返回中文或英文，Details 为可选的补充信息。
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id"`
+}
+
+// Error 以统一格式返回错误并中止后续处理。
+func Error(c *gin.Context, status int, code string, details any) {
+	c.AbortWithStatusJSON(status, ErrorBody{
+		Code:      code,
+		Message:   Message(code, Language(c)),
+		Details:   details,
+		RequestID: c.GetString(RequestIDKey),
+	})
+}
+
+// Language 根据 Accept-Language 选择消息语言，默认为中文。
+func Language(c *gin.Context) string {
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(c.GetHeader("Accept-Language"))), "en") {
+		return "en"
+	}
+	return "zh"
+}
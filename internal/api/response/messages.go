@@ -0,0 +1,119 @@
+package response
+
+// 错误码。客户端应依据错误码而不是消息文本做判断。
+const (
+	CodeInvalidRequest         = "INVALID_REQUEST"
+	CodeMissingAuthToken       = "MISSING_AUTH_TOKEN"
+	CodeSessionExpired         = "SESSION_EXPIRED"
+	CodeLoginFailed            = "LOGIN_FAILED"
+	CodeRateLimited            = "RATE_LIMITED"
+	CodeServerBusy             = "SERVER_BUSY"
+	CodeTestInProgress         = "TEST_IN_PROGRESS"
+	CodeUpstreamUnauthorized   = "UPSTREAM_UNAUTHORIZED"
+	CodePaperUnavailable       = "PAPER_UNAVAILABLE"
+	CodeUpstreamUnavailable    = "UPSTREAM_UNAVAILABLE"
+	CodeUpstreamBadResponse    = "UPSTREAM_BAD_RESPONSE"
+	CodeUpstreamError          = "UPSTREAM_ERROR"
+	CodeAdminDisabled          = "ADMIN_DISABLED"
+	CodeAdminUnauthorized      = "ADMIN_UNAUTHORIZED"
+	CodeUnauthenticated        = "UNAUTHENTICATED"
+	CodeForbidden              = "FORBIDDEN"
+	CodeQuotaExceeded          = "QUOTA_EXCEEDED"
+	CodeNotFound               = "NOT_FOUND"
+	CodeDictionaryReloadFailed = "DICTIONARY_RELOAD_FAILED"
+	CodeInternalError          = "INTERNAL_ERROR"
+)
+
+var messages = map[string]map[string]string{
+	CodeInvalidRequest: {
+		"zh": "请求参数无效",
+		"en": "Invalid request parameters",
+	},
+	CodeMissingAuthToken: {
+		"zh": "缺少 X-Session-ID 或 X-Auth-Token 请求头",
+		"en": "X-Session-ID or X-Auth-Token header is required",
+	},
+	CodeSessionExpired: {
+		"zh": "会话不存在或已过期，请重新登录",
+		"en": "The session does not exist or has expired, please log in again",
+	},
+	CodeLoginFailed: {
+		"zh": "SSO 登录失败",
+		"en": "SSO login failed",
+	},
+	CodeRateLimited: {
+		"zh": "请求频率过快，请稍后再试",
+		"en": "Too many requests, please try again later",
+	},
+	CodeServerBusy: {
+		"zh": "服务器繁忙，请稍后再试",
+		"en": "The server is busy, please try again later",
+	},
+	CodeTestInProgress: {
+		"zh": "该用户已有一个测试正在进行，请等待其完成",
+		"en": "A test for this user is already in progress",
+	},
+	CodeUpstreamUnauthorized: {
+		"zh": "X-Auth-Token 无效或已过期，请重新登录",
+		"en": "X-Auth-Token is invalid or expired, please log in again",
+	},
+	CodePaperUnavailable: {
+		"zh": "试卷不可用 (可能已被领取或未开放)",
+		"en": "The paper is unavailable (already taken or not open yet)",
+	},
+	CodeUpstreamUnavailable: {
+		"zh": "学校平台暂时不可用，请稍后再试",
+		"en": "The school platform is temporarily unavailable, please try again later",
+	},
+	CodeUpstreamBadResponse: {
+		"zh": "学校平台返回了无法解析的数据",
+		"en": "The school platform returned a malformed response",
+	},
+	CodeUpstreamError: {
+		"zh": "学校平台返回错误",
+		"en": "The school platform returned an error",
+	},
+	CodeAdminDisabled: {
+		"zh": "管理接口未启用",
+		"en": "Admin API is disabled",
+	},
+	CodeAdminUnauthorized: {
+		"zh": "管理令牌无效",
+		"en": "Invalid admin token",
+	},
+	CodeUnauthenticated: {
+		"zh": "缺少或无效的 API Key / JWT",
+		"en": "Missing or invalid API key / JWT",
+	},
+	CodeForbidden: {
+		"zh": "没有访问该接口的权限",
+		"en": "You are not allowed to access this endpoint",
+	},
+	CodeQuotaExceeded: {
+		"zh": "今日测试次数已用完",
+		"en": "Daily test quota exceeded",
+	},
+	CodeNotFound: {
+		"zh": "资源不存在",
+		"en": "Resource not found",
+	},
+	CodeDictionaryReloadFailed: {
+		"zh": "重新加载题库失败，仍在使用旧题库",
+		"en": "Failed to reload the dictionary; the previous one is still in use",
+	},
+	CodeInternalError: {
+		"zh": "服务器内部错误",
+		"en": "Internal server error",
+	},
+}
+
+// Message 返回错误码对应语言的消息，未知错误码原样返回。
+func Message(code, lang string) string {
+	if byLang, ok := messages[code]; ok {
+		if msg, ok := byLang[lang]; ok {
+			return msg
+		}
+		return byLang["zh"]
+	}
+	return code
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paper_ids 为用户在平台上做过的试卷；only_wrong 为 true 时只加入答错的单词。
+type CreateDeckRequest struct {
+	Name      string   `json:"name" binding:"max=50"`
+	Week      int      `json:"week" binding:"min=0,max=30"`
+	PaperIDs  []string `json:"paper_ids" binding:"omitempty,max=20,dive,paperid"`
+	OnlyWrong bool     `json:"only_wrong"`
+}
+
+// grade 为 0-5 的回忆质量，3 及以上视为记住。
+type ReviewRequest struct {
+	Word  string `json:"word" binding:"required"`
+	Grade *int   `json:"grade" binding:"required,min=0,max=5"`
+}
+
+type StudyHandler struct {
+	studyService *service.StudyService
+	sessions     *session.Manager
+}
+
+func NewStudyHandler(studyService *service.StudyService, sessions *session.Manager) *StudyHandler {
+	return &StudyHandler{studyService: studyService, sessions: sessions}
+}
+
+func handleStudyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrDeckNotFound), errors.Is(err, repository.ErrCardNotFound):
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, err.Error())
+	default:
+		logging.FromContext(c.Request.Context(), slog.Default()).Error("学习卡组操作失败", "error", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, nil)
+	}
+}
+
+// CreateDeckHandler 用用户做过的试卷创建卡组。paper_ids 为空时使用试卷记录中 week 周的全部试卷。
+func (h *StudyHandler) CreateDeckHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	var req CreateDeckRequest
+	if !bindJSON(c, &req, false) {
+		return
+	}
+	if len(req.PaperIDs) == 0 && req.Week == 0 {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, []FieldError{{Field: "paper_ids", Rule: "required_without", Param: "week"}})
+		return
+	}
+
+	src := service.DeckSource{Name: req.Name, Week: req.Week, PaperIDs: req.PaperIDs, OnlyWrong: req.OnlyWrong}
+	summary, skipped, err := h.studyService.CreateDeck(c.Request.Context(), sess.UpstreamToken, sess.Username, src)
+	if errors.Is(err, service.ErrNoWeekPapers) {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrEmptyDeck) {
+		response.Error(c, http.StatusUnprocessableEntity, response.CodeInvalidRequest, gin.H{"error": err.Error(), "skipped": skipped})
+		return
+	}
+	if err != nil {
+		handleUpstreamError(c, h.sessions, err, "创建学习卡组失败")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"deck": summary, "skipped": skipped})
+}
+
+func (h *StudyHandler) ListDecksHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"decks": h.studyService.ListDecks(sess.Username, time.Now())})
+}
+
+func (h *StudyHandler) GetDeckHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	deck, err := h.studyService.GetDeck(sess.Username, c.Param("deckId"))
+	if err != nil {
+		handleStudyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deck)
+}
+
+func (h *StudyHandler) DeleteDeckHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	if err := h.studyService.DeleteDeck(sess.Username, c.Param("deckId")); err != nil {
+		handleStudyError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DueCardsHandler 返回已到期需要复习的卡片，limit 默认 20。
+func (h *StudyHandler) DueCardsHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(c, "limit", 20, 1, 200)
+	if !ok {
+		return
+	}
+	cards, err := h.studyService.DueCards(sess.Username, c.Param("deckId"), time.Now(), limit)
+	if err != nil {
+		handleStudyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cards": cards})
+}
+
+// ReviewHandler 记录一次复习并返回重新安排后的卡片。
+func (h *StudyHandler) ReviewHandler(c *gin.Context) {
+	sess, ok := requireSession(c)
+	if !ok {
+		return
+	}
+	var req ReviewRequest
+	if !bindJSON(c, &req, false) {
+		return
+	}
+	card, err := h.studyService.Review(sess.Username, c.Param("deckId"), req.Word, *req.Grade, time.Now())
+	if err != nil {
+		handleStudyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, card)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/client"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/middleware"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// upstreamErrorMappings 把上游错误分类映射为 HTTP 状态码和错误码，按顺序匹配。
+var upstreamErrorMappings = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{client.ErrRateLimited, http.StatusTooManyRequests, response.CodeRateLimited},
+	{client.ErrUnauthorized, http.StatusUnauthorized, response.CodeUpstreamUnauthorized},
+	{client.ErrPaperUnavailable, http.StatusConflict, response.CodePaperUnavailable},
+	{client.ErrUpstreamUnavailable, http.StatusServiceUnavailable, response.CodeUpstreamUnavailable},
+	{client.ErrDecode, http.StatusBadGateway, response.CodeUpstreamBadResponse},
+}
+
+// handleUpstreamError 将错误映射为统一的错误响应。上游返回的 code/msg 放在 details.upstream 中。
+func handleUpstreamError(c *gin.Context, sessions *session.Manager, err error, contextMsg string) {
+	status := http.StatusInternalServerError
+	code := response.CodeInternalError
+	details := gin.H{"context": contextMsg, "error": err.Error()}
+
+	var upstreamErr *client.UpstreamError
+	if errors.As(err, &upstreamErr) {
+		status = http.StatusBadGateway
+		code = response.CodeUpstreamError
+		if upstreamErr.Upstream != nil {
+			details["upstream"] = upstreamErr.Upstream
+		}
+	}
+	for _, m := range upstreamErrorMappings {
+		if errors.Is(err, m.kind) {
+			status = m.status
+			code = m.code
+			break
+		}
+	}
+	// 上游令牌已失效时会话也随之失效，要求用户重新登录
+	if sess := middleware.CurrentSession(c); sess != nil && errors.Is(err, client.ErrUnauthorized) {
+		sessions.Expire(sess)
+		code = response.CodeSessionExpired
+	}
+	response.Error(c, status, code, details)
+}
+
+// upstreamToken 返回学校平台的 X-Auth-Token：优先取自会话，其次是 X-Auth-Token 请求头。
+func upstreamToken(c *gin.Context) string {
+	if sess := middleware.CurrentSession(c); sess != nil {
+		return sess.UpstreamToken
+	}
+	return c.GetHeader("X-Auth-Token")
+}
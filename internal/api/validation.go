@@ -0,0 +1,95 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// paperIDPattern 是学校平台试卷 ID 的格式。试卷 ID 会拼进带着用户令牌的上游请求，只允许字母和数字。
+var paperIDPattern = regexp.MustCompile(`^[A-Za-z0-9]{1,64}$`)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		// 校验错误中使用 JSON 字段名 (week) 而不是 Go 字段名 (Week)
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "" || name == "-" {
+				return field.Name
+			}
+			return name
+		})
+		_ = v.RegisterValidation("paperid", func(fl validator.FieldLevel) bool {
+			return paperIDPattern.MatchString(fl.Field().String())
+		})
+	}
+}
+
+// FieldError 描述一个未通过校验的字段。
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+// bindJSON 解析并校验请求体。allowEmpty 为 true 时空请求体视为所有字段取零值。
+// 失败时已写入 400 响应，调用方直接返回即可。
+func bindJSON(c *gin.Context, req any, allowEmpty bool) bool {
+	err := c.ShouldBindJSON(req)
+	if err != nil && allowEmpty && errors.Is(err, io.EOF) {
+		err = binding.Validator.ValidateStruct(req)
+	}
+	if err == nil {
+		return true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{Field: fe.Field(), Rule: fe.Tag(), Param: fe.Param()})
+		}
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, fields)
+		return false
+	}
+	response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, err.Error())
+	return false
+}
+
+// queryInt 读取整数查询参数，缺省时使用 def，不在 [min, max] 范围内时返回错误响应。
+func queryInt(c *gin.Context, name string, def, min, max int) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min || n > max {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, fmt.Sprintf("参数 %s 必须是 %d 到 %d 之间的整数", name, min, max))
+		return 0, false
+	}
+	return n, true
+}
+
+// querySeed 解析可选的随机种子参数 seed，未提供时返回 nil。失败时已写入 400 响应。
+func querySeed(c *gin.Context) (*uint64, bool) {
+	raw := c.Query("seed")
+	if raw == "" {
+		return nil, true
+	}
+	n, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, "参数 seed 必须是非负整数")
+		return nil, false
+	}
+	return &n, true
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WordHandler struct {
+	wordRepo *repository.WordRepository
+}
+
+func NewWordHandler(wordRepo *repository.WordRepository) *WordHandler {
+	return &WordHandler{wordRepo: wordRepo}
+}
+
+// LookupWordHandler 查询单个单词的释义。
+func (h *WordHandler) LookupWordHandler(c *gin.Context) {
+	word, definition, ok := h.wordRepo.LookupDefinition(c.Param("word"))
+	if !ok {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, "题库中没有该单词")
+		return
+	}
+	c.JSON(http.StatusOK, repository.WordEntry{Word: word, Definition: definition})
+}
+
+// SearchWordsHandler 按英文前缀 (prefix) 或中文释义 (meaning) 搜索单词，二者必须且只能提供一个，
+// 支持 offset/limit 分页。
+func (h *WordHandler) SearchWordsHandler(c *gin.Context) {
+	prefix := strings.TrimSpace(c.Query("prefix"))
+	meaning := strings.TrimSpace(c.Query("meaning"))
+	if (prefix == "") == (meaning == "") {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, "必须且只能提供 prefix 或 meaning 其中一个参数")
+		return
+	}
+	offset, ok := queryInt(c, "offset", 0, 0, math.MaxInt32)
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(c, "limit", 20, 1, 100)
+	if !ok {
+		return
+	}
+
+	var words []repository.WordEntry
+	var total int
+	if prefix != "" {
+		words, total = h.wordRepo.SearchPrefix(prefix, offset, limit)
+	} else {
+		words, total = h.wordRepo.SearchMeaning(meaning, offset, limit)
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "offset": offset, "limit": limit, "words": words})
+}
+
+// RandomWordsHandler 随机抽取 count 个不同的单词，count 默认 10；提供 seed 时结果可复现。
+// 同一 seed 下的单词顺序固定，可以用 offset 逐页取完整个题库而不重复；没有 seed 时每次顺序都不同，
+// 分页没有意义，因此 offset 必须配合 seed 使用。
+func (h *WordHandler) RandomWordsHandler(c *gin.Context) {
+	count, ok := queryInt(c, "count", 10, 1, 100)
+	if !ok {
+		return
+	}
+	offset, ok := queryInt(c, "offset", 0, 0, math.MaxInt32)
+	if !ok {
+		return
+	}
+	seed, ok := querySeed(c)
+	if !ok {
+		return
+	}
+	if offset > 0 && seed == nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidRequest, []FieldError{{Field: "seed", Rule: "required_with", Param: "offset"}})
+		return
+	}
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	if seed != nil {
+		rng = rand.New(rand.NewPCG(*seed, *seed))
+	}
+	words, total := h.wordRepo.Sample(offset, count, rng)
+	c.JSON(http.StatusOK, gin.H{"total": total, "offset": offset, "limit": count, "words": words})
+}
@@ -0,0 +1,187 @@
+package apiauth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	ModeNone   = "none"
+	ModeAPIKey = "api_key"
+	ModeJWT    = "jwt"
+
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+var (
+	// ErrMissingCredentials 表示请求没有携带 API Key 或 JWT。
+	ErrMissingCredentials = errors.New("缺少访问凭证")
+	// ErrInvalidCredentials 表示凭证无效、已过期或签名不正确。
+	ErrInvalidCredentials = errors.New("访问凭证无效")
+)
+
+// APIKey 是配置文件中的一个访问密钥。DailyQuota 为每天可以发起的测试次数，0 表示不限制。
+type APIKey struct {
+	Name       string `mapstructure:"name"`
+	Key        string `mapstructure:"key"`
+	Role       string `mapstructure:"role"`
+	DailyQuota int    `mapstructure:"daily_quota"`
+}
+
+// JWTConfig 配置 HS256 签名的 JWT。sub 作为调用方名称，role 声明为 admin 时拥有管理权限，
+// daily_quota 声明可覆盖 DefaultDailyQuota。
+type JWTConfig struct {
+	Secret            string `mapstructure:"secret"`
+	Issuer            string `mapstructure:"issuer"`
+	Audience          string `mapstructure:"audience"`
+	DefaultDailyQuota int    `mapstructure:"default_daily_quota"`
+}
+
+type Config struct {
+	Mode    string    `mapstructure:"mode"` // none | api_key | jwt
+	APIKeys []APIKey  `mapstructure:"api_keys"`
+	JWT     JWTConfig `mapstructure:"jwt"`
+}
+
+// Enabled 报告是否需要对 /api/v1 请求做认证。
+func (c Config) Enabled() bool {
+	return c.Mode != "" && !strings.EqualFold(c.Mode, ModeNone)
+}
+
+// Principal 是认证通过的调用方。
+type Principal struct {
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	DailyQuota int    `json:"daily_quota"`
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p != nil && p.Role == RoleAdmin
+}
+
+type jwtClaims struct {
+	Role       string `json:"role"`
+	DailyQuota *int   `json:"daily_quota"`
+	jwt.RegisteredClaims
+}
+
+// Authenticator 根据配置校验 API Key 或 JWT。
+type Authenticator struct {
+	cfg    Config
+	keys   map[[sha256.Size]byte]APIKey
+	parser *jwt.Parser
+}
+
+func NewAuthenticator(cfg Config) *Authenticator {
+	// mode 和 role 不区分大小写，与配置校验一致
+	cfg.Mode = strings.ToLower(strings.TrimSpace(cfg.Mode))
+	a := &Authenticator{cfg: cfg, keys: make(map[[sha256.Size]byte]APIKey)}
+	for _, key := range cfg.APIKeys {
+		key.Role = strings.ToLower(strings.TrimSpace(key.Role))
+		if key.Role == "" {
+			key.Role = RoleUser
+		}
+		// 以摘要为键查找，避免逐个比较密钥带来的时序差异
+		a.keys[sha256.Sum256([]byte(key.Key))] = key
+	}
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired()}
+	if cfg.JWT.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWT.Issuer))
+	}
+	if cfg.JWT.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWT.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+	return a
+}
+
+func (a *Authenticator) Enabled() bool {
+	return a.cfg.Enabled()
+}
+
+// credential 从 X-API-Key 或 Authorization: Bearer 头中取出凭证。
+func credential(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// Authenticate 校验请求携带的凭证。未启用认证时返回 nil, nil。
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if !a.Enabled() {
+		return nil, nil
+	}
+	cred := credential(r)
+	if cred == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	switch a.cfg.Mode {
+	case ModeAPIKey:
+		key, ok := a.keys[sha256.Sum256([]byte(cred))]
+		if !ok {
+			return nil, ErrInvalidCredentials
+		}
+		return &Principal{Name: key.Name, Role: key.Role, DailyQuota: key.DailyQuota}, nil
+	case ModeJWT:
+		var claims jwtClaims
+		_, err := a.parser.ParseWithClaims(cred, &claims, func(*jwt.Token) (any, error) {
+			return []byte(a.cfg.JWT.Secret), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+		}
+		if claims.Subject == "" {
+			return nil, fmt.Errorf("%w: 缺少 sub 声明", ErrInvalidCredentials)
+		}
+		p := &Principal{Name: claims.Subject, Role: RoleUser, DailyQuota: a.cfg.JWT.DefaultDailyQuota}
+		if claims.Role == RoleAdmin {
+			p.Role = RoleAdmin
+		}
+		if claims.DailyQuota != nil {
+			p.DailyQuota = *claims.DailyQuota
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("未知的认证模式 %q", a.cfg.Mode)
+	}
+}
+
+// QuotaTracker 按调用方统计当天已发起的测试次数。计数只保存在内存中，服务重启后清零。
+type QuotaTracker struct {
+	mu     sync.Mutex
+	date   string
+	counts map[string]int
+}
+
+func NewQuotaTracker() *QuotaTracker {
+	return &QuotaTracker{counts: make(map[string]int)}
+}
+
+// Take 尝试为调用方占用一次额度，成功时返回当天已使用的次数 (含本次)。quota <= 0 表示不限制。
+func (q *QuotaTracker) Take(name string, quota int, now time.Time) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if date := now.Format("2006-01-02"); date != q.date {
+		q.date = date
+		q.counts = make(map[string]int)
+	}
+	if quota > 0 && q.counts[name] >= quota {
+		return q.counts[name], false
+	}
+	q.counts[name]++
+	return q.counts[name], true
+}
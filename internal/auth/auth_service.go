@@ -1,13 +1,16 @@
 package auth
 
 import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/metrics"
+	"context"
 	"crypto/aes"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
@@ -23,13 +26,23 @@ const (
 )
 
 type AuthService struct {
+	logger *slog.Logger
 }
 
-func NewAuthService() (*AuthService, error) {
-	return &AuthService{}, nil
+func NewAuthService(logger *slog.Logger) (*AuthService, error) {
+	return &AuthService{logger: logger}, nil
 }
 
-func (s *AuthService) Login(username, password string) (string, error) {
+// Login 通过 SSO 登录并换取 X-Auth-Token。日志中不会出现密码、AES 密钥或任何令牌。
+func (s *AuthService) Login(ctx context.Context, username, password string) (token string, err error) {
+	defer func() {
+		outcome := "success"
+		if err != nil {
+			outcome = "failure"
+		}
+		metrics.SSOLogins.WithLabelValues(outcome).Inc()
+	}()
+	logger := logging.FromContext(ctx, s.logger).With("component", "auth", "username", username)
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		return "", fmt.Errorf("创建 cookie jar 失败: %w", err)
@@ -46,36 +59,31 @@ func (s *AuthService) Login(username, password string) (string, error) {
 		return "", fmt.Errorf("生成 state token 失败: %w", err)
 	}
 
-	log.Println("已生成 State Token:", stateToken)
 	serviceURLWithState := fmt.Sprintf("%s?state=%s&index=", baseServiceURL, stateToken)
 
-	// log.Println("步骤 1 & 2: 访问登录页并解析令牌...")
+	logger.Debug("访问登录页并解析令牌")
 	croyptoKey, execution, fullLoginURL, err := s.fetLoginTokens(isolatedClient, serviceURLWithState)
 	if err != nil {
 		return "", err
 	}
 
-	log.Printf("    - AES Key: %s, Execution Token (前20): %s...\n", croyptoKey, execution[:20])
-
-	// log.Println("步骤 3: 加密密码...")
 	encryptedPassword, err := s.encryptPassword(croyptoKey, password)
 	if err != nil {
 		return "", err
 	}
-	// log.Println("    - 密码加密成功")
 
-	// log.Println("步骤 4 & 5: 发送登录请求...")
+	logger.Debug("发送登录请求")
 	ticketURL, err := s.postLoginForm(isolatedClient, username, encryptedPassword, croyptoKey, execution, fullLoginURL)
 	if err != nil {
 		return "", err
 	}
-	// log.Println("    - 登录成功，已获取Ticket URL")
-	// log.Println("步骤 6: 访问Ticket URL换取X-Auth-Token...")
-	xAuthToken, err := s.exchangeTicketForToken(isolatedClient, ticketURL, fullLoginURL)
+
+	logger.Debug("访问Ticket URL换取X-Auth-Token")
+	xAuthToken, err := s.exchangeTicketForToken(logger, isolatedClient, ticketURL, fullLoginURL)
 	if err != nil {
 		return "", err
 	}
-	// log.Println("🎉🎉🎉 最终胜利！成功获取 X-Auth-Token！ 🎉🎉🎉")
+	logger.Info("SSO 登录成功")
 	return xAuthToken, nil
 }
 
@@ -161,7 +169,7 @@ func (s *AuthService) postLoginForm(client *http.Client, user, encPass, cryptoKe
 	return location.String(), nil
 }
 
-func (s *AuthService) exchangeTicketForToken(client *http.Client, ticketURL, referer string) (string, error) {
+func (s *AuthService) exchangeTicketForToken(logger *slog.Logger, client *http.Client, ticketURL, referer string) (string, error) {
 	maxRedirects := 10
 	currentURL := ticketURL
 	for i := 0; i < maxRedirects; i++ {
@@ -187,7 +195,7 @@ func (s *AuthService) exchangeTicketForToken(client *http.Client, ticketURL, ref
 			}
 
 			if strings.Contains(parsedLocation.Fragment, "token=") {
-				log.Println("    - 成功！在Location URL的Fragment中找到Token！")
+				logger.Debug("在Location URL的Fragment中找到Token")
 
 				fragmentQuery := strings.TrimPrefix(parsedLocation.Fragment, "?")
 				values, err := url.ParseQuery(fragmentQuery)
@@ -205,12 +213,12 @@ func (s *AuthService) exchangeTicketForToken(client *http.Client, ticketURL, ref
 			continue
 		}
 
-		log.Println("    - 重定向结束，状态码:", resp.StatusCode)
+		logger.Debug("重定向结束", "status", resp.StatusCode)
 		sklURL, _ := url.Parse("https://skl.hdu.edu.cn")
 		cookies := client.Jar.Cookies(sklURL)
 		for _, cookie := range cookies {
 			if cookie.Name == "X-Auth-Token" {
-				log.Println("    - 备用方案：在最终页面的Cookie中找到Token。")
+				logger.Info("备用方案：在最终页面的Cookie中找到Token")
 
 				return cookie.Value, nil
 			}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 上游错误分类，通过 errors.Is 判断；具体的状态码和上游返回的 code/msg 见 *UpstreamError。
+var (
+	ErrRateLimited         = errors.New("request frequency is too fast")
+	ErrUnauthorized        = errors.New("upstream token is invalid or expired")
+	ErrPaperUnavailable    = errors.New("paper is unavailable")
+	ErrUpstreamUnavailable = errors.New("upstream service is unavailable")
+	ErrDecode              = errors.New("failed to decode upstream response")
+)
+
+// UpstreamError 描述一次失败的上游调用。Kind 为上面的分类之一，未能归类时为 nil。
+type UpstreamError struct {
+	Kind       error
+	Op         string
+	StatusCode int
+	Upstream   *model.ErrorResponse
+	Err        error
+}
+
+func (e *UpstreamError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Op)
+	b.WriteString("失败")
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, ": 上游返回状态 %d", e.StatusCode)
+	}
+	if e.Upstream != nil && (e.Upstream.Code != 0 || e.Upstream.Msg != "") {
+		fmt.Fprintf(&b, " (code=%d, msg=%s)", e.Upstream.Code, e.Upstream.Msg)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+	return b.String()
+}
+
+func (e *UpstreamError) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+func transportError(op string, err error) error {
+	return &UpstreamError{Kind: ErrUpstreamUnavailable, Op: op, Err: err}
+}
+
+func decodeError(op string, err error) error {
+	return &UpstreamError{Kind: ErrDecode, Op: op, Err: err}
+}
+
+// statusError 根据非 200 响应的状态码和响应体归类错误。paperOp 表示该接口操作的是某份试卷，
+// 此时 400/404 归为 ErrPaperUnavailable (试卷已被领取、未开放或不存在)。
+func statusError(op string, statusCode int, body []byte, paperOp bool) error {
+	e := &UpstreamError{Op: op, StatusCode: statusCode}
+	var errorResponse model.ErrorResponse
+	if json.Unmarshal(body, &errorResponse) == nil && (errorResponse.Code != 0 || errorResponse.Msg != "") {
+		e.Upstream = &errorResponse
+	}
+
+	switch {
+	case statusCode == http.StatusBadRequest && e.Upstream != nil &&
+		e.Upstream.Code == 2 && strings.Contains(e.Upstream.Msg, "请勿在短时间重试"):
+		e.Kind = ErrRateLimited
+	case statusCode == http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		e.Kind = ErrUnauthorized
+	case statusCode >= http.StatusInternalServerError:
+		e.Kind = ErrUpstreamUnavailable
+	case paperOp && (statusCode == http.StatusBadRequest || statusCode == http.StatusNotFound || statusCode == http.StatusConflict):
+		e.Kind = ErrPaperUnavailable
+	}
+	return e
+}
@@ -1,44 +1,57 @@
 package client
 
 import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
-	"net/http/httputil"
-	"strings"
+	"net/url"
+	"sync"
 	"time"
 )
 
-var ErrRateLimited = errors.New("request frequency is too fast")
-
 type HduApiClient struct {
-	BaseURL    string
-	HTTPClient *http.Client
+	BaseURL string
+	logger  *slog.Logger
+
+	// 超时和重试策略可以通过 Reconfigure 热更新
+	mu         sync.RWMutex
+	httpClient *http.Client
+	retry      RetryPolicy
 }
 
-func NewHduApiClient(baseURL string, timeoutSec int) *HduApiClient {
-	return &HduApiClient{
-		BaseURL: baseURL,
-		HTTPClient: &http.Client{
-			Timeout: time.Duration(timeoutSec) * time.Second,
-		},
-	}
+func NewHduApiClient(baseURL string, timeoutSec int, retry RetryPolicy, logger *slog.Logger) *HduApiClient {
+	c := &HduApiClient{BaseURL: baseURL, logger: logger}
+	c.Reconfigure(timeoutSec, retry)
+	return c
 }
 
-func logRequest(req *http.Request, description string) {
-	dump, err := httputil.DumpRequestOut(req, true)
-	if err != nil {
-		log.Printf("!!! 错误：无法导出请求 '%s': %v", description, err)
-		return
-	}
-	log.Printf("--- HTTP Request Sent: %s ---\n%s\n---------------------------------------\n", description, string(dump))
+// Reconfigure 替换请求超时和重试策略，已经发出的请求不受影响。
+func (c *HduApiClient) Reconfigure(timeoutSec int, retry RetryPolicy) {
+	client := &http.Client{Timeout: time.Duration(timeoutSec) * time.Second}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.httpClient = client
+	c.retry = retry
+}
+
+func (c *HduApiClient) settings() (*http.Client, RetryPolicy) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.httpClient, c.retry
+}
+
+// log 返回带有请求上下文字段 (request_id 等) 的日志器。
+func (c *HduApiClient) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, c.logger).With("component", "hdu_api")
 }
 
 func setCommonHeaders(req *http.Request, xAuthToken, sklTicket string) {
@@ -51,145 +64,145 @@ func setCommonHeaders(req *http.Request, xAuthToken, sklTicket string) {
 	req.Header.Set("skl-ticket", sklTicket)
 }
 
-func (C *HduApiClient) FetchCurrentWeek(xAuthToken string) (*model.CourseInfoResponse, error) {
-	sklTicket, err := utils.GenerateSklTicket()
-	if err != nil {
-		return nil, fmt.Errorf("为获取周数生成票据失败: %w", err)
-	}
+func (C *HduApiClient) FetchCurrentWeek(ctx context.Context, xAuthToken string) (*model.CourseInfoResponse, error) {
 	today := time.Now().Format("2006-01-02")
 	url := fmt.Sprintf("%s/course?startTime=%s", C.BaseURL, today)
 
-	req, _ := http.NewRequest("GET", url, nil)
-	setCommonHeaders(req, xAuthToken, sklTicket)
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("Pragma", "no-cache")
-
-	// logRequest(req, "Fetch Current Week")
-
-	resp, err := C.HTTPClient.Do(req)
+	resp, err := C.do(ctx, "获取当前周数", "course", idempotent, func() (*http.Request, error) {
+		sklTicket, err := utils.GenerateSklTicket()
+		if err != nil {
+			return nil, fmt.Errorf("为获取周数生成票据失败: %w", err)
+		}
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		setCommonHeaders(req, xAuthToken, sklTicket)
+		req.Header.Set("Cache-Control", "no-cache")
+		req.Header.Set("Pragma", "no-cache")
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("获取当前周数请求失败: %w", err)
+		return nil, transportError("获取当前周数", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("获取当前周数API返回错误状态: %s", resp.Status)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, statusError("获取当前周数", resp.StatusCode, bodyBytes, false)
 	}
 
 	var courseInfo model.CourseInfoResponse
 	if err := json.NewDecoder(resp.Body).Decode(&courseInfo); err != nil {
-		return nil, fmt.Errorf("解析周数响应失败: %w", err)
+		return nil, decodeError("解析周数响应", err)
 	}
 
 	return &courseInfo, nil
 }
 
-func (c *HduApiClient) FetchPaperDetail(xAuthToken, paperID string) (*model.PaperDetailResponse, error) {
-	sklTicket, err := utils.GenerateSklTicket()
-	if err != nil {
-		return nil, fmt.Errorf("为获取试卷详情生成票据失败: %w", err)
-	}
-	url := fmt.Sprintf("%s/paper/detail?paperId=%s", c.BaseURL, paperID)
+func (c *HduApiClient) FetchPaperDetail(ctx context.Context, xAuthToken, paperID string) (*model.PaperDetailResponse, error) {
+	// paperID 来自用户输入，需要转义，避免向带着用户令牌的上游请求中注入其他参数
+	endpoint := fmt.Sprintf("%s/paper/detail?%s", c.BaseURL, url.Values{"paperId": {paperID}}.Encode())
 
-	req, _ := http.NewRequest("GET", url, nil)
-	setCommonHeaders(req, xAuthToken, sklTicket)
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("Pragma", "no-cache")
-
-	// logRequest(req, "Fetch Paper Detail")
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(ctx, "获取试卷详情", "paper_detail", idempotent, func() (*http.Request, error) {
+		sklTicket, err := utils.GenerateSklTicket()
+		if err != nil {
+			return nil, fmt.Errorf("为获取试卷详情生成票据失败: %w", err)
+		}
+		req, _ := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		setCommonHeaders(req, xAuthToken, sklTicket)
+		req.Header.Set("Cache-Control", "no-cache")
+		req.Header.Set("Pragma", "no-cache")
+		return req, nil
+	})
 	if err != nil {
 		var netErr net.Error
 		if errors.As(err, &netErr) && netErr.Timeout() {
-			log.Printf("!!! 致命错误 (考后学习): 获取试卷详情时发生网络超时 (配置的超时时间为 %s)", c.HTTPClient.Timeout.String())
+			httpClient, _ := c.settings()
+			c.log(ctx).Error("获取试卷详情时发生网络超时", "timeout", httpClient.Timeout.String())
 		}
-		return nil, fmt.Errorf("获取试卷详情请求失败: %w", err)
+		return nil, transportError("获取试卷详情", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		log.Printf("!!! 错误 (考后学习): 获取试卷详情API返回非200状态。状态码: %s, 响应体: %s", resp.Status, string(bodyBytes))
-		return nil, fmt.Errorf("获取试卷详情API返回错误状态: %s", resp.Status)
+		c.log(ctx).Error("获取试卷详情API返回非200状态", "status", resp.StatusCode, "body", logging.Truncate(string(bodyBytes), 200))
+		return nil, statusError("获取试卷详情", resp.StatusCode, bodyBytes, true)
 	}
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取试卷详情响应体失败: %w", err)
+		return nil, transportError("读取试卷详情响应体", err)
 	}
-	log.Printf("--- Received Paper Detail Response --- (Size: %d bytes)\n", len(bodyBytes))
+	c.log(ctx).Debug("已收到试卷详情", "paper_id", paperID, "bytes", len(bodyBytes))
 
 	var detailResponse model.PaperDetailResponse
 	if err := json.Unmarshal(bodyBytes, &detailResponse); err != nil {
-		log.Printf("!!! 致命错误 (考后学习): 解析试卷详情JSON失败。原始响应体如下: !!!\n%s\n!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!\n", string(bodyBytes))
-		return nil, fmt.Errorf("解析试卷详情响应失败: %w", err)
+		c.log(ctx).Error("解析试卷详情JSON失败", "error", err, "body", logging.Truncate(string(bodyBytes), 200))
+		return nil, decodeError("解析试卷详情响应", err)
 	}
 
 	return &detailResponse, nil
 }
 
-func (c *HduApiClient) GetNewPaper(xAuthToken string, week int, examType string) (*model.PaperResponse, error) {
-	sklTicket, err := utils.GenerateSklTicket()
-	if err != nil {
-		return nil, fmt.Errorf("为获取试卷生成票据失败: %w", err)
-	}
-	startTime := time.Now().UnixMilli()
-	url := fmt.Sprintf("%s/paper/new?type=%s&week=%d&startTime=%d", c.BaseURL, examType, week, startTime)
-
-	req, _ := http.NewRequest("GET", url, nil)
-	setCommonHeaders(req, xAuthToken, sklTicket)
-
-	// logRequest(req, "Get New Paper")
-
-	resp, err := c.HTTPClient.Do(req)
+func (c *HduApiClient) GetNewPaper(ctx context.Context, xAuthToken string, week int, examType string) (*model.PaperResponse, error) {
+	// 获取新试卷不是幂等的：只要请求到达服务器就可能已经生成了一份新卷子，
+	// 因此只在连接失败时重试。
+	resp, err := c.do(ctx, "获取试卷", "paper_new", nonIdempotent, func() (*http.Request, error) {
+		sklTicket, err := utils.GenerateSklTicket()
+		if err != nil {
+			return nil, fmt.Errorf("为获取试卷生成票据失败: %w", err)
+		}
+		startTime := time.Now().UnixMilli()
+		url := fmt.Sprintf("%s/paper/new?type=%s&week=%d&startTime=%d", c.BaseURL, examType, week, startTime)
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		setCommonHeaders(req, xAuthToken, sklTicket)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("发送获取试卷请求失败: %w", err)
+		return nil, transportError("获取试卷", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		if resp.StatusCode == http.StatusBadRequest {
-			var errorResponse model.ErrorResponse
-			if json.Unmarshal(bodyBytes, &errorResponse) == nil {
-				if errorResponse.Code == 2 && strings.Contains(errorResponse.Msg, "请勿在短时间重试") {
-					log.Println("检测到请求频率过快错误。")
-					return nil, ErrRateLimited
-				}
-			}
+		statusErr := statusError("获取试卷", resp.StatusCode, bodyBytes, true)
+		if errors.Is(statusErr, ErrRateLimited) {
+			c.log(ctx).Warn("检测到请求频率过快错误")
 		}
-		return nil, fmt.Errorf("获取试卷API返回错误状态: %s", resp.Status)
+		return nil, statusErr
 	}
 
 	var paperResponse model.PaperResponse
 	if err := json.NewDecoder(resp.Body).Decode(&paperResponse); err != nil {
-		return nil, fmt.Errorf("解析试卷响应失败: %w", err)
+		return nil, decodeError("解析试卷响应", err)
 	}
 
 	return &paperResponse, nil
 }
 
-func (c *HduApiClient) SubmitPaper(xAuthToken string, payload *model.SubmissionPayload) error {
-	sklTicket, err := utils.GenerateSklTicket()
-	if err != nil {
-		return fmt.Errorf("为提交试卷生成票据失败: %w", err)
-	}
+func (c *HduApiClient) SubmitPaper(ctx context.Context, xAuthToken string, payload *model.SubmissionPayload) error {
 	payloadBytes, _ := json.Marshal(payload)
 	url := fmt.Sprintf("%s/paper/save", c.BaseURL)
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
 
-	setCommonHeaders(req, xAuthToken, sklTicket)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Origin", "https://skl.hdu.edu.cn")
-	// logRequest(req, "Submit Paper")
-	resp, err := c.HTTPClient.Do(req)
+	// 提交以 paperId 为键，重复提交同一份答案是安全的
+	resp, err := c.do(ctx, "提交试卷", "paper_save", idempotent, func() (*http.Request, error) {
+		sklTicket, err := utils.GenerateSklTicket()
+		if err != nil {
+			return nil, fmt.Errorf("为提交试卷生成票据失败: %w", err)
+		}
+		req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payloadBytes))
+		setCommonHeaders(req, xAuthToken, sklTicket)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "https://skl.hdu.edu.cn")
+		return req, nil
+	})
 	if err != nil {
-		return err
+		return transportError("提交试卷", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("提交API返回错误状态: %s", resp.Status)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return statusError("提交试卷", resp.StatusCode, bodyBytes, true)
 	}
 
 	return nil
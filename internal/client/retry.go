@@ -0,0 +1,141 @@
+package client
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/metrics"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/tracing"
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RetryPolicy 控制对上游 HDU 接口的重试。MaxAttempts 包含首次请求，<= 1 表示不重试。
+type RetryPolicy struct {
+	MaxAttempts          int     `mapstructure:"max_attempts"`
+	InitialBackoffMs     int     `mapstructure:"initial_backoff_ms"`
+	MaxBackoffMs         int     `mapstructure:"max_backoff_ms"`
+	Multiplier           float64 `mapstructure:"multiplier"`
+	Jitter               float64 `mapstructure:"jitter"`
+	RetryableStatusCodes []int   `mapstructure:"retryable_status_codes"`
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoffMs:     500,
+		MaxBackoffMs:         5000,
+		Multiplier:           2,
+		Jitter:               0.2,
+		RetryableStatusCodes: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// idempotency 描述一个请求能否被安全地重复发送。
+type idempotency int
+
+const (
+	// idempotent 的请求 (查询、以 paperId 为键的提交) 在网络错误和可重试状态码时都会重试。
+	idempotent idempotency = iota
+	// nonIdempotent 的请求 (例如获取新试卷，每次成功都会生成一份新卷子) 只在连接未建立、
+	// 请求确定没有到达服务器时重试。
+	nonIdempotent
+)
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoffMs) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxBackoffMs > 0 && delay > float64(p.MaxBackoffMs) {
+		delay = float64(p.MaxBackoffMs)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	// 400 (频率限制等业务错误) 和 429 必须立即返回给调用方，重试只会让限制更久
+	if code == http.StatusBadRequest || code == http.StatusTooManyRequests {
+		return false
+	}
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// notSent 判断错误是否发生在连接建立阶段，此时请求一定没有被服务器处理。
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// do 按重试策略发送请求。newRequest 每次尝试都会被调用，以便重新生成 skl-ticket 和请求体。
+// endpoint 是用于监控指标和 span 名称的稳定接口名，所有重试共用一个 span。
+func (c *HduApiClient) do(ctx context.Context, description, endpoint string, mode idempotency, newRequest func() (*http.Request, error)) (resp *http.Response, err error) {
+	httpClient, policy := c.settings()
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	ctx, span := tracing.Start(ctx, "hdu_api."+endpoint, attribute.String("hdu_api.endpoint", endpoint))
+	attempt := 1
+	defer func() {
+		span.SetAttributes(attribute.Int("hdu_api.attempts", attempt))
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+		tracing.End(span, err)
+	}()
+
+	for ; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+
+		start := time.Now()
+		resp, err := httpClient.Do(req)
+		statusCode := 0
+		if err == nil {
+			statusCode = resp.StatusCode
+		}
+		metrics.ObserveUpstream(endpoint, statusCode, start)
+
+		retry := false
+		if err != nil {
+			retry = mode == idempotent || notSent(err)
+		} else if mode == idempotent && policy.retryableStatus(resp.StatusCode) {
+			retry = true
+		}
+
+		if !retry || attempt >= attempts {
+			return resp, err
+		}
+
+		wait := policy.backoff(attempt)
+		if err != nil {
+			c.log(ctx).Warn("上游请求失败，准备重试", "op", description, "attempt", attempt, "max_attempts", attempts, "error", err, "backoff", wait)
+		} else {
+			c.log(ctx).Warn("上游返回可重试状态，准备重试", "op", description, "attempt", attempt, "max_attempts", attempts, "status", resp.StatusCode, "backoff", wait)
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPolicy 的退避很短，重试测试不需要真的等待。
+func testPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoffMs:     1,
+		MaxBackoffMs:         5,
+		Multiplier:           2,
+		RetryableStatusCodes: []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusBadGateway},
+	}
+}
+
+func newTestClient(baseURL string, logs *bytes.Buffer) *HduApiClient {
+	if logs == nil {
+		logs = &bytes.Buffer{}
+	}
+	return NewHduApiClient(baseURL, 5, testPolicy(), slog.New(slog.NewTextHandler(logs, nil)))
+}
+
+func TestRetryStatusCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		newPaper bool
+		wantHits int32
+		wantKind error
+	}{
+		{name: "400 频率限制不重试", status: http.StatusBadRequest, body: `{"code":2,"msg":"请勿在短时间重试"}`, wantHits: 1, wantKind: ErrRateLimited},
+		{name: "400 其他错误不重试", status: http.StatusBadRequest, body: `{"code":1,"msg":"试卷不存在"}`, wantHits: 1, wantKind: ErrPaperUnavailable},
+		{name: "429 不重试", status: http.StatusTooManyRequests, wantHits: 1, wantKind: ErrRateLimited},
+		{name: "502 重试到上限", status: http.StatusBadGateway, wantHits: 3, wantKind: ErrUpstreamUnavailable},
+		{name: "获取新试卷 400 不重试", status: http.StatusBadRequest, body: `{"code":2,"msg":"请勿在短时间重试"}`, newPaper: true, wantHits: 1, wantKind: ErrRateLimited},
+		{name: "获取新试卷 429 不重试", status: http.StatusTooManyRequests, newPaper: true, wantHits: 1, wantKind: ErrRateLimited},
+		{name: "获取新试卷 502 不重试", status: http.StatusBadGateway, newPaper: true, wantHits: 1, wantKind: ErrUpstreamUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			c := newTestClient(srv.URL, nil)
+			var err error
+			if tt.newPaper {
+				_, err = c.GetNewPaper(context.Background(), "token", 1, "0")
+			} else {
+				_, err = c.FetchPaperDetail(context.Background(), "token", "p1")
+			}
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("err = %v, want %v", err, tt.wantKind)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("请求次数 = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+// hangUpServer 接受连接、读取请求后直接断开，请求已经到达服务器但没有响应。
+func hangUpServer(t *testing.T) (addr string, accepted *atomic.Int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	accepted = &atomic.Int32{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			conn.Read(make([]byte, 4096))
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String(), accepted
+}
+
+// closedAddr 返回一个没有监听的本地地址，连接会在拨号阶段失败。
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestGetNewPaperRetriesOnlyBeforeSend(t *testing.T) {
+	t.Run("拨号失败时重试", func(t *testing.T) {
+		var logs bytes.Buffer
+		c := newTestClient("http://"+closedAddr(t), &logs)
+		_, err := c.GetNewPaper(context.Background(), "token", 1, "0")
+		if !errors.Is(err, ErrUpstreamUnavailable) {
+			t.Fatalf("err = %v, want %v", err, ErrUpstreamUnavailable)
+		}
+		if got := strings.Count(logs.String(), "上游请求失败，准备重试"); got != 2 {
+			t.Errorf("重试次数 = %d, want 2\n%s", got, logs.String())
+		}
+	})
+
+	t.Run("请求已发出后断开不重试", func(t *testing.T) {
+		addr, accepted := hangUpServer(t)
+		c := newTestClient("http://"+addr, nil)
+		_, err := c.GetNewPaper(context.Background(), "token", 1, "0")
+		if !errors.Is(err, ErrUpstreamUnavailable) {
+			t.Fatalf("err = %v, want %v", err, ErrUpstreamUnavailable)
+		}
+		if got := accepted.Load(); got != 1 {
+			t.Errorf("连接次数 = %d, want 1", got)
+		}
+	})
+
+	t.Run("幂等请求断开后重试", func(t *testing.T) {
+		addr, accepted := hangUpServer(t)
+		c := newTestClient("http://"+addr, nil)
+		if _, err := c.FetchPaperDetail(context.Background(), "token", "p1"); err == nil {
+			t.Fatal("期望返回错误")
+		}
+		if got := accepted.Load(); got != 3 {
+			t.Errorf("连接次数 = %d, want 3", got)
+		}
+	})
+}
+
+func TestNotSent(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "拨号错误", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, want: true},
+		{name: "DNS 错误", err: fmt.Errorf("包装: %w", &net.DNSError{Err: "no such host", Name: "skl.hdu.edu.cn", IsNotFound: true}), want: true},
+		{name: "读取错误", err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, want: false},
+		{name: "超时", err: context.DeadlineExceeded, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notSent(tt.err); got != tt.want {
+				t.Errorf("notSent(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoffHonorsContext(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL, nil)
+	policy := testPolicy()
+	policy.InitialBackoffMs = 10000
+	policy.MaxBackoffMs = 10000
+	c.Reconfigure(5, policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.FetchPaperDetail(ctx, "token", "p1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("取消后仍在等待退避: %v", elapsed)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("请求次数 = %d, want 1", got)
+	}
+}
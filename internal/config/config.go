@@ -0,0 +1,174 @@
+package config
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/apiauth"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/client"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/service"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/tracing"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// Config 是服务的完整配置，字段与 config.yaml 的结构一一对应。
+// 每个字段也可以用 HDU_APP_ 前缀的环境变量覆盖，例如 HDU_APP_AI_SERVICE_API_KEY。
+type Config struct {
+	Server    ServerConfig         `mapstructure:"server"`
+	Logging   logging.Config       `mapstructure:"logging"`
+	HduAPI    HduAPIConfig         `mapstructure:"hdu_api"`
+	AIService AIServiceConfig      `mapstructure:"ai_service"`
+	Database  DatabaseConfig       `mapstructure:"database"`
+	Tracing   tracing.Config       `mapstructure:"tracing"`
+	Health    service.HealthConfig `mapstructure:"health"`
+	Metrics   MetricsConfig        `mapstructure:"metrics"`
+	Admin     AdminConfig          `mapstructure:"admin"`
+	Auth      apiauth.Config       `mapstructure:"auth"`
+	CORS      CORSConfig           `mapstructure:"cors"`
+	Limits    LimitsConfig         `mapstructure:"limits"`
+	Session   session.Config       `mapstructure:"session"`
+}
+
+type ServerConfig struct {
+	Port string `mapstructure:"port"` // 监听地址，例如 ":8080"
+}
+
+type HduAPIConfig struct {
+	BaseURL        string             `mapstructure:"base_url"`
+	TimeoutSeconds int                `mapstructure:"timeout_seconds"`
+	Retry          client.RetryPolicy `mapstructure:"retry"`
+}
+
+type AIServiceConfig struct {
+	BaseURL        string                `mapstructure:"base_url"`
+	APIKey         string                `mapstructure:"api_key"`
+	Model          string                `mapstructure:"model"`
+	TimeoutSeconds int                   `mapstructure:"timeout_seconds"`
+	PromptsDir     string                `mapstructure:"prompts_dir"`
+	Budget         service.BudgetConfig  `mapstructure:"budget"`
+	Pricing        service.PricingConfig `mapstructure:"pricing"`
+	Voting         VotingConfig          `mapstructure:"voting"`
+}
+
+type VotingConfig struct {
+	Providers []service.AIProvider         `mapstructure:"providers"`
+	ExamTypes map[int]service.VotingConfig `mapstructure:"exam_types"`
+}
+
+type DatabaseConfig struct {
+	JSONPath       string `mapstructure:"json_path"`
+	AnswerBankPath string `mapstructure:"answer_bank_path"`
+	// AnswerBankStatsPath 保存答案银行的按日增长统计
+	AnswerBankStatsPath string `mapstructure:"answer_bank_stats_path"`
+	UsagePath           string `mapstructure:"usage_path"`
+	StudyPath           string `mapstructure:"study_path"`    // 学习卡组与复习进度
+	PracticePath        string `mapstructure:"practice_path"` // 练习试卷答案与单词掌握情况
+	PapersPath          string `mapstructure:"papers_path"`   // 用户做过的试卷详情 (错题本)
+	HistoryPath         string `mapstructure:"history_path"`  // 每个用户的测试记录
+}
+
+type MetricsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+type AdminConfig struct {
+	Token string `mapstructure:"token"` // 为空且未启用 auth 时关闭管理接口
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+}
+
+type LimitsConfig struct {
+	GlobalRPS   float64 `mapstructure:"global_rps"` // /api/v1 每秒允许的请求数，0 表示不限制
+	GlobalBurst int     `mapstructure:"global_burst"`
+	// PerUserInFlight 为 true 时同一用户同时只能进行一个测试，重复请求返回 409
+	PerUserInFlight bool `mapstructure:"per_user_in_flight"`
+}
+
+// setDefaults 为每个配置项注册默认值。除了提供默认值，这也让 viper 知道所有的 key，
+// 从而使 Unmarshal 能读取到只通过环境变量设置的配置项。
+func setDefaults(v *viper.Viper) {
+	retry := client.DefaultRetryPolicy()
+	defaults := map[string]any{
+		"server.port": ":8080",
+
+		"logging.level":  "info",
+		"logging.format": "text",
+
+		"hdu_api.base_url":                     "https://skl.hdu.edu.cn/api",
+		"hdu_api.timeout_seconds":              60,
+		"hdu_api.retry.max_attempts":           retry.MaxAttempts,
+		"hdu_api.retry.initial_backoff_ms":     retry.InitialBackoffMs,
+		"hdu_api.retry.max_backoff_ms":         retry.MaxBackoffMs,
+		"hdu_api.retry.multiplier":             retry.Multiplier,
+		"hdu_api.retry.jitter":                 retry.Jitter,
+		"hdu_api.retry.retryable_status_codes": retry.RetryableStatusCodes,
+
+		"ai_service.base_url":                       "https://api.deepseek.com",
+		"ai_service.api_key":                        "",
+		"ai_service.model":                          "deepseek-chat",
+		"ai_service.timeout_seconds":                120,
+		"ai_service.prompts_dir":                    "./config/prompts",
+		"ai_service.budget.daily_tokens":            0,
+		"ai_service.budget.monthly_tokens":          0,
+		"ai_service.budget.per_user_daily_tokens":   0,
+		"ai_service.pricing.prompt_per_million":     0,
+		"ai_service.pricing.completion_per_million": 0,
+		"ai_service.voting.providers":               []any{},
+
+		"database.json_path":              "./database.json",
+		"database.answer_bank_path":       "./answer_bank.json",
+		"database.answer_bank_stats_path": "./answer_bank_stats.json",
+		"database.usage_path":             "./ai_usage.json",
+		"database.study_path":             "./study.json",
+		"database.practice_path":          "./practice.json",
+		"database.papers_path":            "./papers.json",
+		"database.history_path":           "./history.json",
+
+		"tracing.exporter":     "none",
+		"tracing.endpoint":     "",
+		"tracing.insecure":     false,
+		"tracing.service_name": "hdu-word-backend",
+		"tracing.sample_ratio": 1.0,
+
+		"health.check_ai_reachability": false,
+		"health.timeout_seconds":       3,
+
+		"metrics.enabled": true,
+
+		"admin.token": "",
+
+		"auth.mode":                    apiauth.ModeNone,
+		"auth.api_keys":                []any{},
+		"auth.jwt.secret":              "",
+		"auth.jwt.issuer":              "",
+		"auth.jwt.audience":            "",
+		"auth.jwt.default_daily_quota": 0,
+
+		"cors.allowed_origins": []string{"http://localhost:5173", "http://127.0.0.1:5173"},
+
+		"limits.global_rps":         0,
+		"limits.global_burst":       20,
+		"limits.per_user_in_flight": true,
+
+		"session.backend":   session.BackendMemory,
+		"session.file_path": "./sessions.json",
+		"session.ttl_hours": 24,
+	}
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+}
+
+// Load 为 v 注册默认值并解析出 Config。调用方需要先完成 ReadInConfig 和环境变量设置；
+// Load 不做校验，以便 --check-config 在配置无效时也能打印出生效的配置。
+func Load(v *viper.Viper) (*Config, error) {
+	setDefaults(v)
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	return &cfg, nil
+}
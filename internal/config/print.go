@@ -0,0 +1,70 @@
+package config
+
+import (
+	"io"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// secretKeys 中的配置项在打印时会被遮盖。
+var secretKeys = []string{"api_key", "token", "password", "secret"}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	if key == "key" {
+		return true
+	}
+	for _, s := range secretKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask 只保留前 3 个字符，便于确认配置的是哪个密钥。空值保持为空，表示未配置。
+func mask(value any) any {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return value
+	}
+	if len(s) <= 8 {
+		return "****"
+	}
+	return s[:3] + "****"
+}
+
+func maskSecrets(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, inner := range v {
+			if _, ok := inner.(string); ok && isSecretKey(key) {
+				out[key] = mask(inner)
+			} else {
+				out[key] = maskSecrets(inner)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, inner := range v {
+			out[i] = maskSecrets(inner)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// Print 以 YAML 输出生效的配置 (配置文件 + 环境变量 + 默认值)，密钥类字段被遮盖。
+func Print(w io.Writer, v *viper.Viper) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(maskSecrets(v.AllSettings())); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/apiauth"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placeholderAPIKey 是 config.example.yaml 中的占位值，视同未配置。
+const placeholderAPIKey = "sk-*****"
+
+// ValidationError 汇总了所有校验失败的配置项，启动时一次性全部报告。
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "配置无效:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(key, format string, args ...any) {
+	v.problems = append(v.problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) httpURL(key, raw string) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(key, "不是有效的 http(s) 地址: %q", raw)
+	}
+}
+
+func (v *validator) positive(key string, n int) {
+	if n <= 0 {
+		v.addf(key, "必须大于 0，当前为 %d", n)
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return
+		}
+	}
+	v.addf(key, "必须是 %s 之一，当前为 %q", strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) existingFile(key, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		v.addf(key, "文件不存在或不可访问: %v", err)
+	} else if info.IsDir() {
+		v.addf(key, "%q 是目录而不是文件", path)
+	}
+}
+
+func (v *validator) existingDir(key, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		v.addf(key, "目录不存在或不可访问: %v", err)
+	} else if !info.IsDir() {
+		v.addf(key, "%q 不是目录", path)
+	}
+}
+
+// writableFile 检查文件可写；文件不存在时检查能否在其所在目录中创建。
+func (v *validator) writableFile(key, path string) {
+	if path == "" {
+		v.addf(key, "未配置")
+		return
+	}
+	if f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0); err == nil {
+		f.Close()
+		return
+	} else if !os.IsNotExist(err) {
+		v.addf(key, "文件不可写: %v", err)
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".write-check-*")
+	if err != nil {
+		v.addf(key, "文件不存在且无法在所在目录中创建: %v", err)
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// Validate 校验配置，返回的错误为 *ValidationError，包含全部问题。
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	if _, port, err := net.SplitHostPort(c.Server.Port); err != nil {
+		v.addf("server.port", "应为 \":8080\" 或 \"host:port\" 形式，当前为 %q", c.Server.Port)
+	} else if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		v.addf("server.port", "端口 %q 无效", port)
+	}
+
+	v.oneOf("logging.level", c.Logging.Level, "debug", "info", "warn", "warning", "error")
+	v.oneOf("logging.format", c.Logging.Format, "text", "json")
+
+	v.httpURL("hdu_api.base_url", c.HduAPI.BaseURL)
+	v.positive("hdu_api.timeout_seconds", c.HduAPI.TimeoutSeconds)
+	retry := c.HduAPI.Retry
+	if retry.MaxAttempts < 0 {
+		v.addf("hdu_api.retry.max_attempts", "不能为负数")
+	}
+	if retry.InitialBackoffMs < 0 || retry.MaxBackoffMs < 0 {
+		v.addf("hdu_api.retry", "退避时间不能为负数")
+	}
+	if retry.Multiplier < 1 {
+		v.addf("hdu_api.retry.multiplier", "必须不小于 1，当前为 %g", retry.Multiplier)
+	}
+	if retry.Jitter < 0 || retry.Jitter > 1 {
+		v.addf("hdu_api.retry.jitter", "必须在 0 到 1 之间，当前为 %g", retry.Jitter)
+	}
+
+	ai := c.AIService
+	v.httpURL("ai_service.base_url", ai.BaseURL)
+	if ai.APIKey == "" || ai.APIKey == placeholderAPIKey {
+		v.addf("ai_service.api_key", "未配置 (可通过环境变量 HDU_APP_AI_SERVICE_API_KEY 设置)")
+	}
+	if ai.Model == "" {
+		v.addf("ai_service.model", "未配置")
+	}
+	v.positive("ai_service.timeout_seconds", ai.TimeoutSeconds)
+	v.existingDir("ai_service.prompts_dir", ai.PromptsDir)
+	if ai.Budget.DailyTokens < 0 || ai.Budget.MonthlyTokens < 0 || ai.Budget.PerUserDailyTokens < 0 {
+		v.addf("ai_service.budget", "预算不能为负数")
+	}
+	if ai.Pricing.PromptPerMillion < 0 || ai.Pricing.CompletionPerMillion < 0 {
+		v.addf("ai_service.pricing", "价格不能为负数")
+	}
+	for i, p := range ai.Voting.Providers {
+		key := fmt.Sprintf("ai_service.voting.providers[%d]", i)
+		v.httpURL(key+".base_url", p.BaseURL)
+		if p.APIKey == "" || p.APIKey == placeholderAPIKey {
+			v.addf(key+".api_key", "未配置")
+		}
+		if p.Model == "" {
+			v.addf(key+".model", "未配置")
+		}
+	}
+	examTypes := make([]int, 0, len(ai.Voting.ExamTypes))
+	for examType := range ai.Voting.ExamTypes {
+		examTypes = append(examTypes, examType)
+	}
+	sort.Ints(examTypes)
+	for _, examType := range examTypes {
+		voting := ai.Voting.ExamTypes[examType]
+		key := fmt.Sprintf("ai_service.voting.exam_types.%d", examType)
+		if examType != 0 && examType != 1 {
+			v.addf(key, "考试类型只能是 0 (自测) 或 1 (考试)")
+		}
+		if voting.Samples < 0 {
+			v.addf(key+".samples", "不能为负数")
+		}
+		if t := voting.Temperature; t != nil {
+			if *t < 0 || *t > 2 {
+				v.addf(key+".temperature", "必须在 0 到 2 之间，当前为 %g", *t)
+			}
+			// temperature 为 0 时每次采样结果相同，投票没有意义，一致率总是 100%
+			if *t == 0 && voting.Samples > 1 {
+				v.addf(key+".temperature", "投票 (samples > 1) 时不能为 0")
+			}
+		}
+	}
+
+	v.existingFile("database.json_path", c.Database.JSONPath)
+	v.writableFile("database.answer_bank_path", c.Database.AnswerBankPath)
+	v.writableFile("database.answer_bank_stats_path", c.Database.AnswerBankStatsPath)
+	v.writableFile("database.usage_path", c.Database.UsagePath)
+	v.writableFile("database.study_path", c.Database.StudyPath)
+	v.writableFile("database.practice_path", c.Database.PracticePath)
+	v.writableFile("database.papers_path", c.Database.PapersPath)
+	v.writableFile("database.history_path", c.Database.HistoryPath)
+
+	if c.Tracing.Exporter != "" {
+		v.oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.addf("tracing.sample_ratio", "必须在 0 到 1 之间，当前为 %g", c.Tracing.SampleRatio)
+	}
+
+	v.positive("health.timeout_seconds", c.Health.TimeoutSeconds)
+
+	c.validateAuth(v)
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		v.addf("cors.allowed_origins", "至少需要一个允许的来源")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin != "*" {
+			v.httpURL("cors.allowed_origins", origin)
+		}
+	}
+
+	if c.Limits.GlobalRPS < 0 {
+		v.addf("limits.global_rps", "不能为负数")
+	}
+	if c.Limits.GlobalRPS > 0 {
+		v.positive("limits.global_burst", c.Limits.GlobalBurst)
+	}
+
+	v.oneOf("session.backend", c.Session.Backend, session.BackendMemory, session.BackendFile)
+	if strings.EqualFold(c.Session.Backend, session.BackendFile) {
+		v.writableFile("session.file_path", c.Session.FilePath)
+	}
+	v.positive("session.ttl_hours", c.Session.TTLHours)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (c *Config) validateAuth(v *validator) {
+	auth := c.Auth
+	v.oneOf("auth.mode", auth.Mode, apiauth.ModeNone, apiauth.ModeAPIKey, apiauth.ModeJWT)
+	switch strings.ToLower(auth.Mode) {
+	case apiauth.ModeAPIKey:
+		if len(auth.APIKeys) == 0 {
+			v.addf("auth.api_keys", "api_key 模式下至少需要配置一个密钥")
+		}
+		names := make(map[string]bool, len(auth.APIKeys))
+		keys := make(map[string]bool, len(auth.APIKeys))
+		for i, k := range auth.APIKeys {
+			key := fmt.Sprintf("auth.api_keys[%d]", i)
+			if k.Name == "" {
+				v.addf(key+".name", "未配置")
+			} else if names[k.Name] {
+				v.addf(key+".name", "名称 %q 重复", k.Name)
+			}
+			names[k.Name] = true
+			if len(k.Key) < 16 {
+				v.addf(key+".key", "长度至少为 16 个字符")
+			} else if keys[k.Key] {
+				v.addf(key+".key", "与其他密钥重复")
+			}
+			keys[k.Key] = true
+			if k.Role != "" {
+				v.oneOf(key+".role", k.Role, apiauth.RoleUser, apiauth.RoleAdmin)
+			}
+			if k.DailyQuota < 0 {
+				v.addf(key+".daily_quota", "不能为负数")
+			}
+		}
+	case apiauth.ModeJWT:
+		if len(auth.JWT.Secret) < 32 {
+			v.addf("auth.jwt.secret", "jwt 模式下必须配置至少 32 个字符的密钥")
+		}
+		if auth.JWT.DefaultDailyQuota < 0 {
+			v.addf("auth.jwt.default_daily_quota", "不能为负数")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type Config struct {
+	Level  string `mapstructure:"level"`  // debug, info, warn, error
+	Format string `mapstructure:"format"` // text, json
+}
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys 中的属性值在输出前会被替换，避免令牌、密码和登录密钥出现在日志中。
+var sensitiveKeys = map[string]bool{
+	"x_auth_token":  true,
+	"token":         true,
+	"password":      true,
+	"croypto":       true,
+	"croypto_key":   true,
+	"execution":     true,
+	"api_key":       true,
+	"authorization": true,
+	"session_id":    true,
+}
+
+// New 按配置创建日志器，输出到标准输出。
+func New(cfg Config) *slog.Logger {
+	return NewWithWriter(os.Stdout, cfg)
+}
+
+func NewWithWriter(w io.Writer, cfg Config) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       parseLevel(cfg.Level),
+		ReplaceAttr: redact,
+	}
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Format, "json") {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(handler)
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+type contextKey struct{}
+
+// WithContext 把日志器 (通常已附带 request_id 等字段) 放入 context。
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext 取出 context 中的日志器，没有时返回 fallback。
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return fallback
+}
+
+// Truncate 截断过长的文本，用于在调试日志中展示响应片段。
+func Truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "...(truncated)"
+}
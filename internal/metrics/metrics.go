@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "hdu_word"
+
+// 答案来源标签
+const (
+	SourceBank       = "bank"
+	SourceDictionary = "dictionary"
+	SourceAI         = "ai"
+	SourceUnanswered = "unanswered"
+)
+
+var (
+	TestsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tests_processed_total",
+		Help:      "处理的测试数量，按考试类型和结果分类。",
+	}, []string{"exam_type", "outcome"})
+
+	AnswerSourceHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "answer_source_hits_total",
+		Help:      "各答案来源 (bank/dictionary/ai/unanswered) 解决的题目数量。",
+	}, []string{"source"})
+
+	AIRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ai_request_duration_seconds",
+		Help:      "AI chat/completions 请求耗时。",
+		Buckets:   []float64{0.5, 1, 2, 5, 10, 20, 40, 60, 120},
+	}, []string{"model", "mode"})
+
+	AIRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_requests_total",
+		Help:      "AI 请求数量，outcome 为 success/error/bad_format。",
+	}, []string{"model", "mode", "outcome"})
+
+	UpstreamRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "HDU 平台接口请求耗时 (每次尝试单独计数)，status 为 HTTP 状态码或 error。",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint", "status"})
+
+	SSOLogins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sso_logins_total",
+		Help:      "SSO 登录次数，按结果分类。",
+	}, []string{"outcome"})
+
+	LearningRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "learning_runs_total",
+		Help:      "考后学习协程的运行结果。",
+	}, []string{"outcome"})
+
+	RequestsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_rejected_total",
+		Help:      "被本地限流拒绝的请求数量，reason 为 rate_limit/in_flight。",
+	}, []string{"reason"})
+
+	AnswerBankSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "answer_bank_entries",
+		Help:      "答案银行中的已验证答案数量。",
+	})
+)
+
+// ObserveUpstream 记录一次上游请求。statusCode 为 0 表示请求未得到响应。
+func ObserveUpstream(endpoint string, statusCode int, start time.Time) {
+	status := "error"
+	if statusCode != 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	UpstreamRequestDuration.WithLabelValues(endpoint, status).Observe(time.Since(start).Seconds())
+}
+
+// ObserveAI 记录一次 AI 请求的耗时和结果。
+func ObserveAI(model, mode, outcome string, start time.Time) {
+	AIRequestDuration.WithLabelValues(model, mode).Observe(time.Since(start).Seconds())
+	AIRequests.WithLabelValues(model, mode, outcome).Inc()
+}
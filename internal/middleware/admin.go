@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/apiauth"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admin 放行携带正确 X-Admin-Token 的请求，或通过 API 认证且角色为 admin 的调用方。
+// 既没有配置令牌也没有启用 API 认证时管理接口整体关闭。
+func Admin(auth *apiauth.Authenticator, token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" && !auth.Enabled() {
+			response.Error(c, http.StatusForbidden, response.CodeAdminDisabled, "请在配置中设置 admin.token 或启用 auth")
+			return
+		}
+		if provided := c.GetHeader("X-Admin-Token"); token != "" && provided != "" {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				response.Error(c, http.StatusUnauthorized, response.CodeAdminUnauthorized, nil)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		p, err := auth.Authenticate(c.Request)
+		switch {
+		case err != nil || p == nil:
+			response.Error(c, http.StatusUnauthorized, response.CodeAdminUnauthorized, nil)
+			return
+		case !p.IsAdmin():
+			response.Error(c, http.StatusForbidden, response.CodeForbidden, "需要 admin 角色")
+			return
+		}
+		setPrincipal(c, p)
+		c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/apiauth"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "api_principal"
+
+// Principal 返回认证中间件放入 context 的调用方，未启用认证时为 nil。
+func Principal(c *gin.Context) *apiauth.Principal {
+	if p, ok := c.Get(principalKey); ok {
+		return p.(*apiauth.Principal)
+	}
+	return nil
+}
+
+func setPrincipal(c *gin.Context, p *apiauth.Principal) {
+	c.Set(principalKey, p)
+	logger := logging.FromContext(c.Request.Context(), slog.Default()).With("api_client", p.Name)
+	c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), logger))
+}
+
+// APIAuth 要求请求携带有效的 API Key 或 JWT；认证模式为 none 时直接放行。
+func APIAuth(auth *apiauth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, err := auth.Authenticate(c.Request)
+		if err != nil {
+			logging.FromContext(c.Request.Context(), slog.Default()).Info("API 认证失败", "error", err)
+			response.Error(c, http.StatusUnauthorized, response.CodeUnauthenticated, authErrorDetails(err))
+			return
+		}
+		if p != nil {
+			setPrincipal(c, p)
+		}
+		c.Next()
+	}
+}
+
+// Quota 按调用方的每日额度计数，超出时返回 429。额度在请求开始时扣除，失败的测试同样计入。
+func Quota(tracker *apiauth.QuotaTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := Principal(c)
+		if p == nil || p.DailyQuota <= 0 {
+			c.Next()
+			return
+		}
+		used, ok := tracker.Take(p.Name, p.DailyQuota, time.Now())
+		c.Header("X-Quota-Limit", strconv.Itoa(p.DailyQuota))
+		c.Header("X-Quota-Remaining", strconv.Itoa(max(p.DailyQuota-used, 0)))
+		if !ok {
+			response.Error(c, http.StatusTooManyRequests, response.CodeQuotaExceeded, gin.H{"daily_quota": p.DailyQuota, "used": used})
+			return
+		}
+		c.Next()
+	}
+}
+
+// authErrorDetails 只向客户端说明缺少凭证还是凭证无效，不暴露校验细节。
+func authErrorDetails(err error) string {
+	if errors.Is(err, apiauth.ErrMissingCredentials) {
+		return "请通过 X-API-Key 或 Authorization: Bearer 提供凭证"
+	}
+	return "凭证无效或已过期"
+}
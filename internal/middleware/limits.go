@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/metrics"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter 是整个服务共享的令牌桶，可在运行时调整速率。
+type RateLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewRateLimiter 创建限流器，rps <= 0 表示不限制。
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	l := &RateLimiter{limiter: rate.NewLimiter(rate.Inf, 1)}
+	l.Set(rps, burst)
+	return l
+}
+
+func (l *RateLimiter) Set(rps float64, burst int) {
+	limit := rate.Limit(rps)
+	if rps <= 0 {
+		limit = rate.Inf
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	l.limiter.SetLimit(limit)
+	l.limiter.SetBurst(burst)
+}
+
+// RateLimit 超出全局速率时返回 429。
+func RateLimit(l *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.limiter.Allow() {
+			metrics.RequestsRejected.WithLabelValues("rate_limit").Inc()
+			c.Header("Retry-After", "1")
+			response.Error(c, http.StatusTooManyRequests, response.CodeServerBusy, nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// InFlightGuard 记录每个用户正在进行的测试。同一用户并发领取试卷时上游只会接受一个，
+// 其余请求会被当作频率限制拒绝，因此在本地直接拒绝。
+type InFlightGuard struct {
+	enabled atomic.Bool
+	mu      sync.Mutex
+	active  map[string]time.Time
+}
+
+func NewInFlightGuard(enabled bool) *InFlightGuard {
+	g := &InFlightGuard{active: make(map[string]time.Time)}
+	g.enabled.Store(enabled)
+	return g
+}
+
+func (g *InFlightGuard) SetEnabled(enabled bool) {
+	g.enabled.Store(enabled)
+}
+
+// acquire 同时占用 keys 中的所有 key。任意一个已被占用时不占用任何 key，并返回其占用开始的时间。
+func (g *InFlightGuard) acquire(keys []string) (time.Time, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, key := range keys {
+		if since, busy := g.active[key]; busy {
+			return since, false
+		}
+	}
+	now := time.Now()
+	for _, key := range keys {
+		g.active[key] = now
+	}
+	return time.Time{}, true
+}
+
+func (g *InFlightGuard) release(keys []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, key := range keys {
+		delete(g.active, key)
+	}
+}
+
+// maxGuardBodyBytes 是读取请求体中 username 时允许的最大请求体，登录请求远小于该值。
+const maxGuardBodyBytes = 64 << 10
+
+// OneTestPerUser 保证同一用户 (会话、X-Auth-Token 或请求体中的 username) 同时只有一个测试在处理，
+// 重复的请求返回 409。
+func OneTestPerUser(g *InFlightGuard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !g.enabled.Load() {
+			c.Next()
+			return
+		}
+		keys, err := userKeys(c)
+		if err != nil {
+			response.Error(c, http.StatusRequestEntityTooLarge, response.CodeInvalidRequest, "请求体过大")
+			return
+		}
+		if len(keys) == 0 {
+			c.Next()
+			return
+		}
+		since, ok := g.acquire(keys)
+		if !ok {
+			metrics.RequestsRejected.WithLabelValues("in_flight").Inc()
+			response.Error(c, http.StatusConflict, response.CodeTestInProgress, gin.H{"started_at": since})
+			return
+		}
+		defer g.release(keys)
+		c.Next()
+	}
+}
+
+// userKeys 返回请求对应的所有身份：用户名 (来自会话或请求体中的 username，读取后放回请求体)
+// 和上游令牌 (来自会话或 X-Auth-Token)。会话请求同时占用两者，因此与直接传同一个令牌的请求、
+// 以同一用户名登录的请求都互斥。只保存摘要，避免令牌常驻内存。必须放在 Session 之后。
+func userKeys(c *gin.Context) ([]string, error) {
+	if s := CurrentSession(c); s != nil {
+		return []string{usernameKey(s.Username), tokenKey(s.UpstreamToken)}, nil
+	}
+	if token := c.GetHeader("X-Auth-Token"); token != "" {
+		return []string{tokenKey(token)}, nil
+	}
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxGuardBodyBytes))
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	var req struct {
+		Username string `json:"username"`
+	}
+	if json.Unmarshal(body, &req) != nil {
+		return nil, nil
+	}
+	if strings.TrimSpace(req.Username) != "" {
+		return []string{usernameKey(req.Username)}, nil
+	}
+	return nil, nil
+}
+
+func usernameKey(username string) string {
+	return "user:" + digest(strings.ToLower(strings.TrimSpace(username)))
+}
+
+func tokenKey(token string) string {
+	return "token:" + digest(token)
+}
+
+func digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
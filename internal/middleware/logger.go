@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/tracing"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Logger 为请求的 context 附上带 request_id (以及启用追踪时的 trace_id) 的日志器，
+// 并在请求结束后记录访问日志。必须放在 RequestID 之后。
+func Logger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := c.GetString(response.RequestIDKey)
+		reqLogger := logger.With("request_id", requestID)
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			reqLogger = reqLogger.With("trace_id", traceID)
+			trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("http.request_id", requestID))
+		}
+		c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), reqLogger))
+
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		reqLogger.Log(c.Request.Context(), level, "HTTP请求",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import "sync/atomic"
+
+// AllowedOrigins 是可热更新的 CORS 来源白名单，供 cors.Config.AllowOriginFunc 使用。
+// 列表中的 "*" 表示允许任意来源。
+type AllowedOrigins struct {
+	origins atomic.Pointer[map[string]bool]
+}
+
+func NewAllowedOrigins(origins []string) *AllowedOrigins {
+	a := &AllowedOrigins{}
+	a.Set(origins)
+	return a
+}
+
+// Set 原子地替换白名单。
+func (a *AllowedOrigins) Set(origins []string) {
+	m := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		m[origin] = true
+	}
+	a.origins.Store(&m)
+}
+
+func (a *AllowedOrigins) Allow(origin string) bool {
+	m := *a.origins.Load()
+	return m["*"] || m[origin]
+}
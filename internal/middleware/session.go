@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHeader 是客户端携带会话 ID 的请求头，登录成功时也通过该响应头返回。
+const SessionHeader = "X-Session-ID"
+
+const sessionKey = "session"
+
+// CurrentSession 返回本次请求的会话，请求未携带 X-Session-ID 时为 nil。
+func CurrentSession(c *gin.Context) *session.Session {
+	if s, ok := c.Get(sessionKey); ok {
+		return s.(*session.Session)
+	}
+	return nil
+}
+
+// SetSession 把会话放入请求 context，供后续中间件和错误处理使用。
+func SetSession(c *gin.Context, s *session.Session) {
+	c.Set(sessionKey, s)
+	logger := logging.FromContext(c.Request.Context(), slog.Default()).With("username", s.Username)
+	c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), logger))
+}
+
+// Session 解析 X-Session-ID。未携带时直接放行 (兼容直接传 X-Auth-Token 的客户端)，
+// 会话不存在或已过期时返回 401。
+func Session(m *session.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(SessionHeader)
+		if id == "" {
+			c.Next()
+			return
+		}
+		s, err := m.Get(id)
+		if err != nil {
+			response.Error(c, http.StatusUnauthorized, response.CodeSessionExpired, nil)
+			return
+		}
+		SetSession(c, s)
+		c.Next()
+	}
+}
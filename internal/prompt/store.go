@@ -0,0 +1,311 @@
+package prompt
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 模板种类。每个模板文件必须定义 "system" 和 "user" 两个子模板。
+const (
+	KindSingleEnToZh = "single_en2zh"
+	KindSingleZhToEn = "single_zh2en"
+	KindBatch        = "batch"
+)
+
+var requiredKinds = []string{KindSingleEnToZh, KindSingleZhToEn, KindBatch}
+
+// DictEntry 是注入提示词的词典条目。
+type DictEntry struct {
+	Word       string
+	Definition string
+}
+
+// SingleData 是单题模板的数据，模板中可直接引用 model.Question 的字段，例如 {{.Title}}；
+// Dictionary 为题目和选项在本地词库中查到的相关词条。
+type SingleData struct {
+	model.Question
+	Dictionary []DictEntry
+}
+
+// BatchData 是批量模板的数据。
+type BatchData struct {
+	Count     int
+	Questions []SingleData
+}
+
+// SingleKind 根据题目方向选择单题模板。
+func SingleKind(q model.Question) string {
+	if utils.IsEnglish(q.Title) {
+		return KindSingleEnToZh
+	}
+	return KindSingleZhToEn
+}
+
+var funcs = template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}
+
+// Store 从目录加载提示词模板:
+//
+//	<dir>/single_en2zh.tmpl, <dir>/single_zh2en.tmpl, <dir>/batch.tmpl  默认模板
+//	<dir>/models/<模型名>/<种类>.tmpl                                   针对某个模型的覆盖
+type Store struct {
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	dir      string
+	defaults map[string]*template.Template
+	byModel  map[string]map[string]*template.Template
+
+	watchMu sync.Mutex
+	watcher *fsnotify.Watcher
+	watched []string
+}
+
+func NewStore(dir string, logger *slog.Logger) (*Store, error) {
+	s := &Store{logger: logger.With("component", "prompt")}
+	if err := s.load(dir); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload 重新加载并校验全部模板；失败时保留旧模板不变。
+func (s *Store) Reload() error {
+	s.mu.RLock()
+	dir := s.dir
+	s.mu.RUnlock()
+	return s.load(dir)
+}
+
+// SetDir 切换到新的模板目录。新目录中的模板校验失败时保持原目录不变；
+// 已开启监听时会改为监听新目录。
+func (s *Store) SetDir(dir string) error {
+	if err := s.load(dir); err != nil {
+		return err
+	}
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.watcher != nil {
+		return s.watchDirs()
+	}
+	return nil
+}
+
+func (s *Store) load(dir string) error {
+	defaults, err := loadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, kind := range requiredKinds {
+		if _, ok := defaults[kind]; !ok {
+			return fmt.Errorf("提示词目录 '%s' 缺少模板 %s.tmpl", dir, kind)
+		}
+	}
+
+	byModel := make(map[string]map[string]*template.Template)
+	modelsDir := filepath.Join(dir, "models")
+	entries, err := os.ReadDir(modelsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取模型提示词目录失败: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		templates, err := loadDir(filepath.Join(modelsDir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		byModel[entry.Name()] = templates
+	}
+
+	s.mu.Lock()
+	s.dir = dir
+	s.defaults = defaults
+	s.byModel = byModel
+	s.mu.Unlock()
+	s.logger.Info("提示词模板加载完成", "dir", dir, "defaults", len(defaults), "model_overrides", len(byModel))
+	return nil
+}
+
+func loadDir(dir string) (map[string]*template.Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取提示词目录 '%s' 失败: %w", dir, err)
+	}
+	templates := make(map[string]*template.Template)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".tmpl" {
+			continue
+		}
+		kind := strings.TrimSuffix(name, ".tmpl")
+		if !isKnownKind(kind) {
+			return nil, fmt.Errorf("未知的提示词模板 '%s'", filepath.Join(dir, name))
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("读取提示词模板 '%s' 失败: %w", name, err)
+		}
+		t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("解析提示词模板 '%s' 失败: %w", filepath.Join(dir, name), err)
+		}
+		if err := validate(kind, t); err != nil {
+			return nil, fmt.Errorf("校验提示词模板 '%s' 失败: %w", filepath.Join(dir, name), err)
+		}
+		templates[kind] = t
+	}
+	return templates, nil
+}
+
+func isKnownKind(kind string) bool {
+	for _, k := range requiredKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// validate 用示例数据执行模板，引用了 model.Question 中不存在的字段时会在启动时报错。
+func validate(kind string, t *template.Template) error {
+	sample := model.Question{
+		PaperDetailID: "sample",
+		Title:         "sample",
+		AnswerA:       "A",
+		AnswerB:       "B",
+		AnswerC:       "C",
+		AnswerD:       "D",
+	}
+	single := SingleData{
+		Question:   sample,
+		Dictionary: []DictEntry{{Word: "sample", Definition: "n. 样本"}},
+	}
+	var data any = single
+	if kind == KindBatch {
+		data = BatchData{Count: 2, Questions: []SingleData{single, single}}
+	}
+	for _, name := range []string{"system", "user"} {
+		if t.Lookup(name) == nil {
+			return fmt.Errorf("缺少 {{define \"%s\"}} 子模板", name)
+		}
+		if err := t.ExecuteTemplate(&bytes.Buffer{}, name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render 渲染指定种类的模板，优先使用 modelName 对应的覆盖模板。
+func (s *Store) Render(kind, modelName string, data any) (systemPrompt, userPrompt string, err error) {
+	s.mu.RLock()
+	t, ok := s.byModel[modelName][kind]
+	if !ok {
+		t = s.defaults[kind]
+	}
+	s.mu.RUnlock()
+	if t == nil {
+		return "", "", fmt.Errorf("未找到提示词模板 '%s'", kind)
+	}
+
+	var systemBuf, userBuf bytes.Buffer
+	if err := t.ExecuteTemplate(&systemBuf, "system", data); err != nil {
+		return "", "", fmt.Errorf("渲染提示词模板 '%s' 失败: %w", kind, err)
+	}
+	if err := t.ExecuteTemplate(&userBuf, "user", data); err != nil {
+		return "", "", fmt.Errorf("渲染提示词模板 '%s' 失败: %w", kind, err)
+	}
+	return strings.TrimSpace(systemBuf.String()), strings.TrimSpace(userBuf.String()), nil
+}
+
+// Watch 监听模板目录，文件变化后自动重新加载。
+func (s *Store) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建提示词目录监听失败: %w", err)
+	}
+	s.watchMu.Lock()
+	s.watcher = watcher
+	if err := s.watchDirs(); err != nil {
+		s.watcher = nil
+		s.watchMu.Unlock()
+		watcher.Close()
+		return err
+	}
+	s.watchMu.Unlock()
+
+	go func() {
+		defer watcher.Close()
+		// 编辑器保存文件时往往会触发多个事件，合并 500ms 内的变化后再重新加载
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&fsnotify.Create != 0 {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						_ = watcher.Add(event.Name)
+					}
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(500*time.Millisecond, func() {
+					if err := s.Reload(); err != nil {
+						s.logger.Error("热加载提示词模板失败，继续使用旧模板", "error", err)
+					}
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				s.logger.Warn("提示词目录监听出错", "error", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// watchDirs 让 watcher 只监听当前模板目录及其 models 子目录。调用方需持有 watchMu。
+func (s *Store) watchDirs() error {
+	s.mu.RLock()
+	dir := s.dir
+	s.mu.RUnlock()
+
+	dirs := []string{dir}
+	modelsDir := filepath.Join(dir, "models")
+	if entries, err := os.ReadDir(modelsDir); err == nil {
+		dirs = append(dirs, modelsDir)
+		for _, entry := range entries {
+			if entry.IsDir() {
+				dirs = append(dirs, filepath.Join(modelsDir, entry.Name()))
+			}
+		}
+	}
+	for _, old := range s.watched {
+		_ = s.watcher.Remove(old)
+	}
+	s.watched = nil
+	for _, d := range dirs {
+		if err := s.watcher.Add(d); err != nil {
+			return fmt.Errorf("监听提示词目录 '%s' 失败: %w", d, err)
+		}
+		s.watched = append(s.watched, d)
+	}
+	return nil
+}
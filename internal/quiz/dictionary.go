@@ -0,0 +1,97 @@
+package quiz
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// gluedTag 匹配直接跟在中文后面的词性标记
+var gluedTag = regexp.MustCompile(`[a-z]+\.`)
+
+// posTags 是释义中出现的词性标记，按长度降序排列以优先匹配较长的标记 (如 adv 先于 ad)。
+var posTags = []string{"abbr", "pron", "prep", "conj", "adj", "adv", "aux", "num", "int", "art", "vt", "vi", "ad", "n", "v", "a"}
+
+// posAliases 把同类词性归并，出题时同一组内的单词互为干扰项。
+var posAliases = map[string]string{
+	"vt": "v",
+	"vi": "v",
+	"a":  "adj",
+	"ad": "adv",
+}
+
+// entry 是一个可用于出题的单词：POS 为第一个词性，Meanings 为该词性下的中文释义。
+type entry struct {
+	Word       string
+	Definition string
+	POS        string
+	Meanings   []string
+}
+
+// splitTag 识别以 "词性." 开头的词元，返回归并后的词性和其余部分。
+func splitTag(token string) (string, string) {
+	for _, tag := range posTags {
+		if strings.HasPrefix(token, tag+".") {
+			pos := tag
+			if alias, ok := posAliases[tag]; ok {
+				pos = alias
+			}
+			return pos, token[len(tag)+1:]
+		}
+	}
+	return "", token
+}
+
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEntry 解析 "音标 词性.释义 释义 词性.释义" 形式的释义。没有词性标记的词条无法出题。
+func parseEntry(word, definition string) (entry, bool) {
+	e := entry{Word: word, Definition: definition}
+	for _, token := range strings.Fields(definition) {
+		// "n. &vt. &vi.希望" 中的多个词性共用同一组释义
+		pos, rest := splitTag(strings.TrimLeft(token, "&"))
+		if pos != "" {
+			if e.POS != "" && pos != e.POS && len(e.Meanings) > 0 {
+				break
+			}
+			e.POS = pos
+			token = rest
+			// "aux.v.能" 这样连写的词性只取第一个
+			for pos != "" {
+				pos, rest = splitTag(token)
+				token = rest
+			}
+		}
+		if i := strings.Index(token, "="); i >= 0 {
+			token = token[:i]
+		}
+		// 去掉 "(pl.)废墟" 这样的前置说明
+		if strings.HasPrefix(token, "(") {
+			if i := strings.Index(token, ")"); i >= 0 {
+				token = token[i+1:]
+			}
+		}
+		// "不管怎样conj.然而" 中紧跟的下一个词性不属于当前这组释义
+		next := -1
+		if loc := gluedTag.FindStringIndex(token); loc != nil {
+			if pos, _ := splitTag(token[loc[0]:]); pos != "" {
+				token, next = token[:loc[0]], loc[0]
+			}
+		}
+		if e.POS == "" || strings.HasPrefix(token, "[") || !hasHan(token) {
+			continue
+		}
+		e.Meanings = append(e.Meanings, strings.Trim(token, " .,;，；"))
+		if next >= 0 {
+			break
+		}
+	}
+	return e, e.POS != "" && len(e.Meanings) > 0
+}
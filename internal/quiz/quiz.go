@@ -0,0 +1,199 @@
+package quiz
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+)
+
+// 题目方向
+const (
+	ModeEnToZh = "en_zh" // 英译中：题干为单词，选项为中文释义
+	ModeZhToEn = "zh_en" // 中译英：题干为中文释义，选项为单词
+	ModeMixed  = "mixed"
+)
+
+var optionLetters = [4]string{"A", "B", "C", "D"}
+
+// ErrNotEnoughWords 表示题库中可用于出题的单词不足。
+var ErrNotEnoughWords = errors.New("题库中可用于出题的单词不足")
+
+// KeyItem 是一道题的标准答案及考查的单词。
+type KeyItem struct {
+	Word   string `json:"word"`
+	Answer string `json:"answer"`
+	Mode   string `json:"mode"`
+}
+
+// Quiz 是一套练习试卷。Paper 与学校平台返回的试卷结构相同，Key 以 paperDetailId 为键。
+type Quiz struct {
+	Paper model.PaperResponse `json:"paper"`
+	Key   map[string]KeyItem  `json:"key"`
+}
+
+// Options 控制出题。Rand 为空时使用随机种子；Rand 只决定题目内容，试卷和题目 ID 总是随机生成。
+type Options struct {
+	Count int
+	Mode  string
+	Rand  *rand.Rand
+}
+
+// Generator 基于 database.json 的单词释义出题。创建后只读，可并发使用。
+type Generator struct {
+	entries []entry
+	byPOS   map[string][]int
+}
+
+// NewGenerator 解析所有释义并按词性分组。
+func NewGenerator(wordToDefinition map[string]string) *Generator {
+	words := make([]string, 0, len(wordToDefinition))
+	for word := range wordToDefinition {
+		words = append(words, word)
+	}
+	// 固定顺序，使相同的随机种子生成相同的试卷
+	sort.Strings(words)
+
+	g := &Generator{byPOS: make(map[string][]int)}
+	for _, word := range words {
+		e, ok := parseEntry(word, wordToDefinition[word])
+		if !ok {
+			continue
+		}
+		g.byPOS[e.POS] = append(g.byPOS[e.POS], len(g.entries))
+		g.entries = append(g.entries, e)
+	}
+	return g
+}
+
+// Size 返回可用于出题的单词数。
+func (g *Generator) Size() int {
+	return len(g.entries)
+}
+
+func level(word string) int {
+	switch n := len(word); {
+	case n <= 5:
+		return 1
+	case n <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// newID 生成试卷和题目 ID。ID 总是取自 crypto/rand 而不是出题用的随机数，
+// 使用相同种子出题也不会得到相同的 ID，无法据此猜出其他练习试卷的答案。
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = cryptorand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Generate 生成一套试卷，同一套试卷中的单词不重复。
+func (g *Generator) Generate(opts Options) (*Quiz, error) {
+	r := opts.Rand
+	if r == nil {
+		r = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	mode := opts.Mode
+	if mode == "" {
+		mode = ModeMixed
+	}
+	if mode != ModeEnToZh && mode != ModeZhToEn && mode != ModeMixed {
+		return nil, fmt.Errorf("未知的出题方式 %q", mode)
+	}
+	if opts.Count <= 0 || opts.Count > len(g.entries) {
+		return nil, ErrNotEnoughWords
+	}
+
+	quiz := &Quiz{
+		Paper: model.PaperResponse{PaperID: "practice-" + newID()},
+		Key:   make(map[string]KeyItem, opts.Count),
+	}
+	for _, idx := range r.Perm(len(g.entries)) {
+		if len(quiz.Paper.List) == opts.Count {
+			break
+		}
+		questionMode := mode
+		if mode == ModeMixed {
+			questionMode = ModeEnToZh
+			if r.IntN(2) == 1 {
+				questionMode = ModeZhToEn
+			}
+		}
+		q, answer, ok := g.question(r, idx, questionMode)
+		if !ok {
+			continue
+		}
+		q.PaperDetailID = newID()
+		quiz.Paper.List = append(quiz.Paper.List, q)
+		quiz.Key[q.PaperDetailID] = KeyItem{Word: g.entries[idx].Word, Answer: answer, Mode: questionMode}
+	}
+	if len(quiz.Paper.List) < opts.Count {
+		return nil, ErrNotEnoughWords
+	}
+	return quiz, nil
+}
+
+// question 以 entries[idx] 为正确答案出一道题，干扰项取自同一词性的其他单词。
+// 干扰项不能出现在正确单词的释义中 (英译中)，其释义也不能包含题干 (中译英)，以保证答案唯一。
+func (g *Generator) question(r *rand.Rand, idx int, mode string) (model.Question, string, bool) {
+	target := g.entries[idx]
+	meaning := target.Meanings[r.IntN(len(target.Meanings))]
+	correct, title := meaning, target.Word
+	if mode == ModeZhToEn {
+		correct, title = target.Word, meaning
+	}
+
+	options := []string{correct}
+	seen := map[string]bool{correct: true}
+	candidates := g.byPOS[target.POS]
+	for _, c := range r.Perm(len(candidates)) {
+		if len(options) == len(optionLetters) {
+			break
+		}
+		other := g.entries[candidates[c]]
+		if other.Word == target.Word {
+			continue
+		}
+		option := other.Word
+		if mode == ModeEnToZh {
+			option = other.Meanings[0]
+			if strings.Contains(target.Definition, option) {
+				continue
+			}
+		} else if strings.Contains(other.Definition, meaning) {
+			continue
+		}
+		if seen[option] {
+			continue
+		}
+		seen[option] = true
+		options = append(options, option)
+	}
+	if len(options) < len(optionLetters) {
+		return model.Question{}, "", false
+	}
+
+	r.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+	answer := ""
+	for i, option := range options {
+		if option == correct {
+			answer = optionLetters[i]
+		}
+	}
+	q := model.Question{
+		Title:   title,
+		AnswerA: options[0],
+		AnswerB: options[1],
+		AnswerC: options[2],
+		AnswerD: options[3],
+		Level:   level(target.Word),
+	}
+	return q, answer, true
+}
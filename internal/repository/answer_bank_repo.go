@@ -1,27 +1,82 @@
 package repository
 
 import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/metrics"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
+const recentAdditionsLimit = 200
+
+// ErrAnswerNotFound 表示答案银行中没有对应的条目。
+var ErrAnswerNotFound = errors.New("答案银行中不存在该条目")
+
+// BankEntry 是答案银行中的一条记录。ID 由指纹计算得到，便于在 URL 中引用。
+type BankEntry struct {
+	ID          string `json:"id"`
+	Fingerprint string `json:"fingerprint"`
+	Title       string `json:"title"`
+	AnswerA     string `json:"answer_a"`
+	AnswerB     string `json:"answer_b"`
+	AnswerC     string `json:"answer_c"`
+	AnswerD     string `json:"answer_d"`
+	Answer      string `json:"answer"`
+}
+
+// BankAddition 是学习协程新增的一条答案，只保存在内存中。
+type BankAddition struct {
+	Time    time.Time `json:"time"`
+	PaperID string    `json:"paper_id"`
+	BankEntry
+}
+
+// BankGrowth 是某一天答案银行的变化量。
+type BankGrowth struct {
+	Date    string `json:"date"`
+	Added   int    `json:"added"`
+	Updated int    `json:"updated"`
+	Deleted int    `json:"deleted"`
+}
+
+// BankStats 是答案银行的规模和增长统计。
+type BankStats struct {
+	Entries int          `json:"entries"`
+	Days    []BankGrowth `json:"days"`
+}
+
 type AnswerBankRepository struct {
-	filePath string
-	mu       sync.RWMutex
-	bank     map[string]string
+	filePath  string
+	statsPath string
+	mu        sync.RWMutex
+	bank      map[string]string
+	ids       map[string]string // ID -> 指纹
+	growth    map[string]*BankGrowth
+	recent    []BankAddition
+	logger    *slog.Logger
 }
 
-func NewAnswerBankRepository(filePath string) (*AnswerBankRepository, error) {
+// NewAnswerBankRepository 加载答案银行。statsPath 保存按日的增长统计。
+func NewAnswerBankRepository(filePath, statsPath string, logger *slog.Logger) (*AnswerBankRepository, error) {
 	repo := &AnswerBankRepository{
-		filePath: filePath,
-		bank:     make(map[string]string),
+		filePath:  filePath,
+		statsPath: statsPath,
+		bank:      make(map[string]string),
+		growth:    make(map[string]*BankGrowth),
+		logger:    logger.With("component", "answer_bank"),
 	}
 	if err := repo.load(); err != nil {
 		if os.IsNotExist(err) {
-			fmt.Println("答案银行文件不存在，将创建一个新的。")
+			repo.logger.Info("答案银行文件不存在，将创建一个新的")
 			if err := repo.persist(); err != nil {
 				return nil, err
 			}
@@ -29,19 +84,23 @@ func NewAnswerBankRepository(filePath string) (*AnswerBankRepository, error) {
 			return nil, err
 		}
 	}
+	if err := repo.loadStats(); err != nil {
+		repo.logger.Warn("答案银行增长统计加载失败，将重新开始统计", "path", statsPath, "error", err)
+	}
 
-	fmt.Printf("答案银行加载完成，当前包含 %d 条已验证答案。\n", len(repo.bank))
-	log.Printf("[AnswerBank] 仓库已初始化，文件路径: '%s'", filePath)
+	repo.ids = make(map[string]string, len(repo.bank))
+	for fingerprint := range repo.bank {
+		repo.ids[entryID(fingerprint)] = fingerprint
+	}
+
+	metrics.AnswerBankSize.Set(float64(len(repo.bank)))
+	repo.logger.Info("答案银行仓库已初始化", "path", filePath, "entries", len(repo.bank))
 	return repo, nil
 }
 
 func (r *AnswerBankRepository) load() error {
-	// log.Println("[AnswerBank] 正在尝试加锁 (读取) 并加载文件...")
 	r.mu.Lock()
-	defer func() {
-		r.mu.Unlock()
-		// log.Println("[AnswerBank] 解锁 (读取) 完成。")
-	}()
+	defer r.mu.Unlock()
 
 	byteValue, err := os.ReadFile(r.filePath)
 	if err != nil {
@@ -50,37 +109,89 @@ func (r *AnswerBankRepository) load() error {
 
 	if len(byteValue) == 0 {
 		r.bank = make(map[string]string)
-		log.Println("[AnswerBank] 加载完成: 文件为空，已初始化空题库。")
+		r.logger.Info("答案银行文件为空，已初始化空题库")
 		return nil
 	}
 
 	err = json.Unmarshal(byteValue, &r.bank)
 	if err != nil {
-		log.Printf("[AnswerBank] 加载失败: 解析JSON错误: %v", err)
+		r.logger.Error("答案银行加载失败: 解析JSON错误", "error", err)
 	} else {
-		log.Printf("[AnswerBank] 加载完成: 成功解析 %d 条记录。", len(r.bank))
+		r.logger.Debug("答案银行加载完成", "entries", len(r.bank))
 	}
 
 	return err
 }
 
+func (r *AnswerBankRepository) loadStats() error {
+	byteValue, err := os.ReadFile(r.statsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(byteValue) == 0 {
+		return nil
+	}
+	return json.Unmarshal(byteValue, &r.growth)
+}
+
 func (r *AnswerBankRepository) persist() error {
-	// log.Println("[AnswerBank] 正在尝试加锁 (写入) 并持久化文件...")
 	byteValue, err := json.MarshalIndent(r.bank, "", "  ")
 	if err != nil {
-		log.Printf("[AnswerBank] 持久化失败: 序列化JSON错误: %v", err)
+		r.logger.Error("答案银行持久化失败: 序列化JSON错误", "error", err)
 		return err
 	}
 
-	err = os.WriteFile(r.filePath, byteValue, 0644)
+	err = utils.WriteFileAtomic(r.filePath, byteValue, 0644)
 	if err != nil {
-		log.Printf("[AnswerBank] 持久化失败: 写入文件错误: %v", err)
+		r.logger.Error("答案银行持久化失败: 写入文件错误", "error", err)
 	} else {
-		log.Printf("[AnswerBank] 持久化成功: %d 条记录已写入 '%s'。", len(r.bank), r.filePath)
+		metrics.AnswerBankSize.Set(float64(len(r.bank)))
+		r.logger.Info("答案银行持久化成功", "entries", len(r.bank), "path", r.filePath)
 	}
 	return err
 }
 
+// persistStats 保存增长统计。统计只用于展示，写入失败只记录日志。
+func (r *AnswerBankRepository) persistStats() {
+	byteValue, err := json.MarshalIndent(r.growth, "", "  ")
+	if err == nil {
+		err = utils.WriteFileAtomic(r.statsPath, byteValue, 0644)
+	}
+	if err != nil {
+		r.logger.Warn("答案银行增长统计持久化失败", "path", r.statsPath, "error", err)
+	}
+}
+
+func (r *AnswerBankRepository) growthLocked(now time.Time) *BankGrowth {
+	date := now.Format("2006-01-02")
+	g, ok := r.growth[date]
+	if !ok {
+		g = &BankGrowth{Date: date}
+		r.growth[date] = g
+	}
+	return g
+}
+
+func entryID(fingerprint string) string {
+	sum := sha256.Sum256([]byte(fingerprint))
+	return hex.EncodeToString(sum[:8])
+}
+
+// newEntry 把 "题目|A|B|C|D" 形式的指纹拆分为字段。
+func newEntry(fingerprint, answer string) BankEntry {
+	entry := BankEntry{ID: entryID(fingerprint), Fingerprint: fingerprint, Answer: answer}
+	parts := strings.Split(fingerprint, "|")
+	if len(parts) != 5 {
+		entry.Title = fingerprint
+		return entry
+	}
+	entry.Title, entry.AnswerA, entry.AnswerB, entry.AnswerC, entry.AnswerD = parts[0], parts[1], parts[2], parts[3], parts[4]
+	return entry
+}
+
 func (r *AnswerBankRepository) Query(fingerprint string) (string, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -89,28 +200,173 @@ func (r *AnswerBankRepository) Query(fingerprint string) (string, bool) {
 	return answer, found
 }
 
-func (r *AnswerBankRepository) Save(newAnswers map[string]string) error {
-	// log.Println("[AnswerBank] 收到保存新答案的请求...")
+// Save 保存学习到的新答案，已存在的指纹不会被覆盖。paperID 用于记录新增答案的来源。
+func (r *AnswerBankRepository) Save(newAnswers map[string]string, paperID string) error {
 	r.mu.Lock()
-	defer func() {
-		r.mu.Unlock()
-		// log.Println("[AnswerBank] 解锁 (写入) 完成。")
-	}()
-	// log.Println("[AnswerBank] 已获取写锁。")
+	defer r.mu.Unlock()
 
-	addedCount := 0
+	now := time.Now()
+	var added []BankAddition
 	for fingerprint, answer := range newAnswers {
 		if _, exists := r.bank[fingerprint]; !exists {
 			r.bank[fingerprint] = answer
-			addedCount++
+			added = append(added, BankAddition{Time: now, PaperID: paperID, BankEntry: newEntry(fingerprint, answer)})
+		}
+	}
+	if len(added) == 0 {
+		r.logger.Info("没有需要学习的新答案")
+		return nil
+	}
+
+	r.logger.Info("发现新答案，准备持久化", "added", len(added))
+	if err := r.persist(); err != nil {
+		for _, a := range added {
+			delete(r.bank, a.Fingerprint)
+		}
+		return err
+	}
+	// 写入成功后才更新 ID 索引、最近新增和增长统计，避免展示没有落盘的条目
+	for _, a := range added {
+		r.ids[a.ID] = a.Fingerprint
+	}
+	r.recent = append(r.recent, added...)
+	if len(r.recent) > recentAdditionsLimit {
+		r.recent = r.recent[len(r.recent)-recentAdditionsLimit:]
+	}
+	r.growthLocked(now).Added += len(added)
+	r.persistStats()
+	return nil
+}
+
+// Size 返回答案银行中的条目数。
+func (r *AnswerBankRepository) Size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.bank)
+}
+
+// CheckWritable 检查答案银行文件能否写入，不会修改文件内容。
+func (r *AnswerBankRepository) CheckWritable() error {
+	f, err := os.OpenFile(r.filePath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("答案银行文件不可写 '%s': %w", r.filePath, err)
+	}
+	return f.Close()
+}
+
+// Search 按题目子串 (不区分大小写) 搜索，结果按题目排序后分页；query 为空时返回全部。
+func (r *AnswerBankRepository) Search(query string, offset, limit int) ([]BankEntry, int) {
+	r.mu.RLock()
+	query = strings.ToLower(strings.TrimSpace(query))
+	var matched []BankEntry
+	for fingerprint, answer := range r.bank {
+		entry := newEntry(fingerprint, answer)
+		if query == "" || strings.Contains(strings.ToLower(entry.Title), query) {
+			matched = append(matched, entry)
 		}
 	}
+	r.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Title != matched[j].Title {
+			return matched[i].Title < matched[j].Title
+		}
+		return matched[i].Fingerprint < matched[j].Fingerprint
+	})
+	total := len(matched)
+	if offset >= total {
+		return []BankEntry{}, total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total
+}
+
+// Get 按 ID 返回条目。
+func (r *AnswerBankRepository) Get(id string) (BankEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fingerprint, ok := r.ids[id]
+	if !ok {
+		return BankEntry{}, ErrAnswerNotFound
+	}
+	return newEntry(fingerprint, r.bank[fingerprint]), nil
+}
 
-	if addedCount > 0 {
-		log.Printf("[AnswerBank] 发现 %d 条新答案，准备持久化...", addedCount)
-		return r.persist()
+// Update 修正一条已有答案并立即持久化。
+func (r *AnswerBankRepository) Update(id, answer string) (BankEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fingerprint, ok := r.ids[id]
+	if !ok {
+		return BankEntry{}, ErrAnswerNotFound
+	}
+	previous := r.bank[fingerprint]
+	r.bank[fingerprint] = answer
+	if err := r.persist(); err != nil {
+		r.bank[fingerprint] = previous
+		return BankEntry{}, err
 	}
+	r.logger.Info("答案已被管理员修正", "id", id, "previous", previous, "answer", answer)
+	r.growthLocked(time.Now()).Updated++
+	r.persistStats()
+	return newEntry(fingerprint, answer), nil
+}
+
+// Delete 删除一条答案并立即持久化。
+func (r *AnswerBankRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	log.Println("[AnswerBank] 没有需要学习的新答案。")
+	fingerprint, ok := r.ids[id]
+	if !ok {
+		return ErrAnswerNotFound
+	}
+	previous := r.bank[fingerprint]
+	delete(r.bank, fingerprint)
+	if err := r.persist(); err != nil {
+		r.bank[fingerprint] = previous
+		return err
+	}
+	delete(r.ids, id)
+	r.logger.Info("答案已被管理员删除", "id", id, "answer", previous)
+	r.growthLocked(time.Now()).Deleted++
+	r.persistStats()
 	return nil
 }
+
+// Recent 返回本次运行以来学习协程新增的答案，最新的在前。
+func (r *AnswerBankRepository) Recent(limit int) []BankAddition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	additions := make([]BankAddition, 0, len(r.recent))
+	for i := len(r.recent) - 1; i >= 0; i-- {
+		additions = append(additions, r.recent[i])
+		if limit > 0 && len(additions) >= limit {
+			break
+		}
+	}
+	return additions
+}
+
+// Stats 返回当前规模和最近 days 天 (含今天) 的按日增长，按日期倒序。
+func (r *AnswerBankRepository) Stats(now time.Time, days int) BankStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := BankStats{Entries: len(r.bank), Days: make([]BankGrowth, 0, days)}
+	for i := 0; i < days; i++ {
+		date := now.AddDate(0, 0, -i).Format("2006-01-02")
+		growth := BankGrowth{Date: date}
+		if g, ok := r.growth[date]; ok {
+			growth = *g
+		}
+		stats.Days = append(stats.Days, growth)
+	}
+	return stats
+}
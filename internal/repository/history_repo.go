@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AnswerSources 是一次测试中各答案来源作答的题数。Modified 为按正确率要求故意改错的题数。
+type AnswerSources struct {
+	Bank       int `json:"bank"`
+	Dictionary int `json:"dictionary"`
+	AI         int `json:"ai"`
+	Unanswered int `json:"unanswered"`
+	Modified   int `json:"modified"`
+}
+
+// TestRecord 是一次自动化测试的记录。Mark 在提交后从试卷详情中获取，获取前为 nil。
+type TestRecord struct {
+	PaperID          string        `json:"paper_id"`
+	Week             int           `json:"week"`
+	ExamType         int           `json:"exam_type"`
+	Questions        int           `json:"questions"`
+	RequestedCorrect *int          `json:"requested_correct,omitempty"`
+	Mark             *int          `json:"mark"`
+	Sources          AnswerSources `json:"sources"`
+	DelaySeconds     int           `json:"delay_seconds"`
+	StartedAt        time.Time     `json:"started_at"`
+	SubmittedAt      time.Time     `json:"submitted_at"`
+	DurationMs       int64         `json:"duration_ms"`
+}
+
+// HistoryRepository 按用户保存测试记录，每次修改后整体写入 JSON 文件。
+type HistoryRepository struct {
+	filePath string
+	mu       sync.RWMutex
+	records  map[string][]TestRecord
+	logger   *slog.Logger
+}
+
+func NewHistoryRepository(filePath string, logger *slog.Logger) (*HistoryRepository, error) {
+	repo := &HistoryRepository{
+		filePath: filePath,
+		records:  make(map[string][]TestRecord),
+		logger:   logger.With("component", "history"),
+	}
+	byteValue, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(byteValue) > 0 {
+		if err := json.Unmarshal(byteValue, &repo.records); err != nil {
+			repo.logger.Error("测试记录加载失败: 解析JSON错误", "error", err)
+			return nil, err
+		}
+	}
+	repo.logger.Info("测试记录仓库已初始化", "path", filePath, "users", len(repo.records))
+	return repo, nil
+}
+
+func (r *HistoryRepository) persist() error {
+	byteValue, err := json.MarshalIndent(r.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(r.filePath, byteValue, 0644); err != nil {
+		r.logger.Error("测试记录持久化失败: 写入文件错误", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *HistoryRepository) Add(username string, record TestRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.records[username]
+	r.records[username] = append(previous, record)
+	if err := r.persist(); err != nil {
+		r.records[username] = previous
+		return err
+	}
+	return nil
+}
+
+// SetMark 记录试卷的得分。找不到对应记录时返回 ErrPaperNotFound。
+func (r *HistoryRepository) SetMark(username, paperID string, mark int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := r.records[username]
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].PaperID != paperID {
+			continue
+		}
+		previous := records[i].Mark
+		records[i].Mark = &mark
+		if err := r.persist(); err != nil {
+			records[i].Mark = previous
+			return err
+		}
+		return nil
+	}
+	return ErrPaperNotFound
+}
+
+// List 返回用户的所有测试记录，最近的在前。
+func (r *HistoryRepository) List(username string) []TestRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := append([]TestRecord(nil), r.records[username]...)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].StartedAt.After(records[j].StartedAt) })
+	return records
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrPaperNotFound 表示用户没有该试卷的记录。
+var ErrPaperNotFound = errors.New("试卷记录不存在")
+
+// PaperRecord 是用户做过的一套试卷在学校平台上的批改详情，包括每道题的作答和对错。
+type PaperRecord struct {
+	PaperID   string                 `json:"paper_id"`
+	Week      int                    `json:"week"`
+	ExamType  int                    `json:"exam_type"`
+	Mark      int                    `json:"mark"`
+	FetchedAt time.Time              `json:"fetched_at"`
+	List      []model.QuestionDetail `json:"list"`
+}
+
+// PaperRepository 按 用户 -> 试卷 保存试卷详情，每次修改后整体写入 JSON 文件。
+type PaperRepository struct {
+	filePath string
+	mu       sync.RWMutex
+	papers   map[string]map[string]*PaperRecord
+	logger   *slog.Logger
+}
+
+func NewPaperRepository(filePath string, logger *slog.Logger) (*PaperRepository, error) {
+	repo := &PaperRepository{
+		filePath: filePath,
+		papers:   make(map[string]map[string]*PaperRecord),
+		logger:   logger.With("component", "papers"),
+	}
+	byteValue, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(byteValue) > 0 {
+		if err := json.Unmarshal(byteValue, &repo.papers); err != nil {
+			repo.logger.Error("试卷记录加载失败: 解析JSON错误", "error", err)
+			return nil, err
+		}
+	}
+	repo.logger.Info("试卷记录仓库已初始化", "path", filePath, "users", len(repo.papers))
+	return repo, nil
+}
+
+func (r *PaperRepository) persist() error {
+	byteValue, err := json.MarshalIndent(r.papers, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(r.filePath, byteValue, 0644); err != nil {
+		r.logger.Error("试卷记录持久化失败: 写入文件错误", "error", err)
+		return err
+	}
+	return nil
+}
+
+// Save 保存用户的一套试卷详情，已存在的同一试卷会被覆盖。
+func (r *PaperRepository) Save(username string, record PaperRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	userPapers, ok := r.papers[username]
+	if !ok {
+		userPapers = make(map[string]*PaperRecord)
+		r.papers[username] = userPapers
+	}
+	previous, existed := userPapers[record.PaperID]
+	userPapers[record.PaperID] = &record
+	if err := r.persist(); err != nil {
+		if existed {
+			userPapers[record.PaperID] = previous
+		} else {
+			delete(userPapers, record.PaperID)
+		}
+		return err
+	}
+	return nil
+}
+
+// List 返回用户的所有试卷记录，按获取时间倒序。返回的记录与仓库共享题目列表，调用方不能修改。
+func (r *PaperRepository) List(username string) []PaperRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := make([]PaperRecord, 0, len(r.papers[username]))
+	for _, p := range r.papers[username] {
+		records = append(records, *p)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].FetchedAt.After(records[j].FetchedAt) })
+	return records
+}
+
+func (r *PaperRepository) Get(username, paperID string) (PaperRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.papers[username][paperID]
+	if !ok {
+		return PaperRecord{}, ErrPaperNotFound
+	}
+	return *p, nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/quiz"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// practicePaperTTL 是练习试卷的有效期，过期未提交的试卷会被清理。
+const practicePaperTTL = 24 * time.Hour
+
+var (
+	// ErrPracticeNotFound 表示练习试卷不存在、已过期或已经提交过。
+	ErrPracticeNotFound = errors.New("练习试卷不存在或已提交")
+	// ErrPracticeExists 表示已有相同 ID 的待提交试卷。
+	ErrPracticeExists = errors.New("练习试卷 ID 已存在")
+)
+
+// PracticePaper 是一套已发给用户、等待提交的练习试卷及其答案。
+type PracticePaper struct {
+	Username  string                  `json:"username"`
+	CreatedAt time.Time               `json:"created_at"`
+	Key       map[string]quiz.KeyItem `json:"key"`
+}
+
+// WordStat 是用户在练习中某个单词的作答记录。
+type WordStat struct {
+	Word        string    `json:"word"`
+	Correct     int       `json:"correct"`
+	Incorrect   int       `json:"incorrect"`
+	LastCorrect bool      `json:"last_correct"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// WordResult 是一道练习题的批改结果。
+type WordResult struct {
+	Word  string
+	Right bool
+}
+
+type practiceData struct {
+	Papers  map[string]*PracticePaper       `json:"papers"`
+	Mastery map[string]map[string]*WordStat `json:"mastery"`
+}
+
+// PracticeRepository 保存待提交的练习试卷和 用户 -> 单词 的作答记录，每次修改后整体写入 JSON 文件。
+type PracticeRepository struct {
+	filePath string
+	mu       sync.RWMutex
+	data     practiceData
+	logger   *slog.Logger
+}
+
+func NewPracticeRepository(filePath string, logger *slog.Logger) (*PracticeRepository, error) {
+	repo := &PracticeRepository{
+		filePath: filePath,
+		logger:   logger.With("component", "practice"),
+	}
+	byteValue, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(byteValue) > 0 {
+		if err := json.Unmarshal(byteValue, &repo.data); err != nil {
+			repo.logger.Error("练习记录加载失败: 解析JSON错误", "error", err)
+			return nil, err
+		}
+	}
+	if repo.data.Papers == nil {
+		repo.data.Papers = make(map[string]*PracticePaper)
+	}
+	if repo.data.Mastery == nil {
+		repo.data.Mastery = make(map[string]map[string]*WordStat)
+	}
+	repo.logger.Info("练习记录仓库已初始化", "path", filePath, "pending_papers", len(repo.data.Papers), "users", len(repo.data.Mastery))
+	return repo, nil
+}
+
+func (r *PracticeRepository) persist() error {
+	byteValue, err := json.MarshalIndent(r.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(r.filePath, byteValue, 0644); err != nil {
+		r.logger.Error("练习记录持久化失败: 写入文件错误", "error", err)
+		return err
+	}
+	return nil
+}
+
+// pruneExpired 删除过期未提交的试卷，调用方需持有写锁。
+func (r *PracticeRepository) pruneExpired(now time.Time) {
+	for id, p := range r.data.Papers {
+		if now.Sub(p.CreatedAt) > practicePaperTTL {
+			delete(r.data.Papers, id)
+		}
+	}
+}
+
+// SavePaper 保存一套新发出的练习试卷，不会覆盖已有的同 ID 试卷。
+func (r *PracticeRepository) SavePaper(paperID string, paper PracticePaper) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pruneExpired(paper.CreatedAt)
+	if _, ok := r.data.Papers[paperID]; ok {
+		return ErrPracticeExists
+	}
+	r.data.Papers[paperID] = &paper
+	if err := r.persist(); err != nil {
+		delete(r.data.Papers, paperID)
+		return err
+	}
+	return nil
+}
+
+// CompletePaper 用 grade 批改用户的一套待提交试卷，把结果累加到用户的单词记录中，
+// 并移除该试卷，保证每套试卷只能提交一次。
+func (r *PracticeRepository) CompletePaper(username, paperID string, now time.Time, grade func(PracticePaper) []WordResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.data.Papers[paperID]
+	if !ok || p.Username != username || now.Sub(p.CreatedAt) > practicePaperTTL {
+		return ErrPracticeNotFound
+	}
+	stats, ok := r.data.Mastery[username]
+	if !ok {
+		stats = make(map[string]*WordStat)
+		r.data.Mastery[username] = stats
+	}
+	previous := make(map[string]*WordStat)
+	for _, res := range grade(*p) {
+		s, ok := stats[res.Word]
+		if _, saved := previous[res.Word]; !saved {
+			if ok {
+				clone := *s
+				previous[res.Word] = &clone
+			} else {
+				previous[res.Word] = nil
+			}
+		}
+		if !ok {
+			s = &WordStat{Word: res.Word}
+			stats[res.Word] = s
+		}
+		if res.Right {
+			s.Correct++
+		} else {
+			s.Incorrect++
+		}
+		s.LastCorrect = res.Right
+		s.LastSeen = now
+	}
+	delete(r.data.Papers, paperID)
+
+	if err := r.persist(); err != nil {
+		r.data.Papers[paperID] = p
+		for word, s := range previous {
+			if s == nil {
+				delete(stats, word)
+			} else {
+				stats[word] = s
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// WordStats 返回用户所有单词的作答记录。
+func (r *PracticeRepository) WordStats(username string) []WordStat {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make([]WordStat, 0, len(r.data.Mastery[username]))
+	for _, s := range r.data.Mastery[username] {
+		stats = append(stats, *s)
+	}
+	return stats
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDeckNotFound 表示用户没有该卡组。
+	ErrDeckNotFound = errors.New("卡组不存在")
+	// ErrCardNotFound 表示卡组中没有该单词。
+	ErrCardNotFound = errors.New("卡组中不存在该单词")
+)
+
+// StudyCard 是卡组中的一个单词及其复习进度 (SM-2)。
+type StudyCard struct {
+	Word         string     `json:"word"`
+	Definition   string     `json:"definition"`
+	Easiness     float64    `json:"easiness"`
+	IntervalDays int        `json:"interval_days"`
+	Repetitions  int        `json:"repetitions"`
+	Lapses       int        `json:"lapses"`
+	Due          time.Time  `json:"due"`
+	LastReview   *time.Time `json:"last_review,omitempty"`
+}
+
+// StudyDeck 是用户的一个卡组。
+type StudyDeck struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Week      int         `json:"week,omitempty"`
+	PaperIDs  []string    `json:"paper_ids,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	Cards     []StudyCard `json:"cards"`
+}
+
+// StudyRepository 按 用户 -> 卡组 保存学习进度，每次修改后整体写入 JSON 文件。
+type StudyRepository struct {
+	filePath string
+	mu       sync.RWMutex
+	decks    map[string]map[string]*StudyDeck
+	logger   *slog.Logger
+}
+
+func NewStudyRepository(filePath string, logger *slog.Logger) (*StudyRepository, error) {
+	repo := &StudyRepository{
+		filePath: filePath,
+		decks:    make(map[string]map[string]*StudyDeck),
+		logger:   logger.With("component", "study"),
+	}
+	byteValue, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(byteValue) > 0 {
+		if err := json.Unmarshal(byteValue, &repo.decks); err != nil {
+			repo.logger.Error("学习卡组加载失败: 解析JSON错误", "error", err)
+			return nil, err
+		}
+	}
+	repo.logger.Info("学习卡组仓库已初始化", "path", filePath, "users", len(repo.decks))
+	return repo, nil
+}
+
+func (r *StudyRepository) persist() error {
+	byteValue, err := json.MarshalIndent(r.decks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(r.filePath, byteValue, 0644); err != nil {
+		r.logger.Error("学习卡组持久化失败: 写入文件错误", "error", err)
+		return err
+	}
+	return nil
+}
+
+func newDeckID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func cloneDeck(d *StudyDeck) StudyDeck {
+	clone := *d
+	clone.PaperIDs = append([]string(nil), d.PaperIDs...)
+	clone.Cards = append([]StudyCard(nil), d.Cards...)
+	return clone
+}
+
+// CreateDeck 为用户保存一个新卡组，ID 和创建时间由仓库填写。
+func (r *StudyRepository) CreateDeck(username string, deck StudyDeck) (StudyDeck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deck.ID = newDeckID()
+	deck.CreatedAt = time.Now()
+	userDecks, ok := r.decks[username]
+	if !ok {
+		userDecks = make(map[string]*StudyDeck)
+		r.decks[username] = userDecks
+	}
+	userDecks[deck.ID] = &deck
+	if err := r.persist(); err != nil {
+		delete(userDecks, deck.ID)
+		return StudyDeck{}, err
+	}
+	return cloneDeck(&deck), nil
+}
+
+// ListDecks 返回用户的所有卡组，按创建时间倒序。
+func (r *StudyRepository) ListDecks(username string) []StudyDeck {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	decks := make([]StudyDeck, 0, len(r.decks[username]))
+	for _, d := range r.decks[username] {
+		decks = append(decks, cloneDeck(d))
+	}
+	sort.Slice(decks, func(i, j int) bool { return decks[i].CreatedAt.After(decks[j].CreatedAt) })
+	return decks
+}
+
+func (r *StudyRepository) GetDeck(username, deckID string) (StudyDeck, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.decks[username][deckID]
+	if !ok {
+		return StudyDeck{}, ErrDeckNotFound
+	}
+	return cloneDeck(d), nil
+}
+
+// UpdateCard 用 update 修改卡组中的一张卡片并立即持久化，返回修改后的卡片。
+func (r *StudyRepository) UpdateCard(username, deckID, word string, update func(*StudyCard)) (StudyCard, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d, ok := r.decks[username][deckID]
+	if !ok {
+		return StudyCard{}, ErrDeckNotFound
+	}
+	for i := range d.Cards {
+		if d.Cards[i].Word != word {
+			continue
+		}
+		previous := d.Cards[i]
+		update(&d.Cards[i])
+		if err := r.persist(); err != nil {
+			d.Cards[i] = previous
+			return StudyCard{}, err
+		}
+		return d.Cards[i], nil
+	}
+	return StudyCard{}, ErrCardNotFound
+}
+
+func (r *StudyRepository) DeleteDeck(username, deckID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d, ok := r.decks[username][deckID]
+	if !ok {
+		return ErrDeckNotFound
+	}
+	delete(r.decks[username], deckID)
+	if err := r.persist(); err != nil {
+		r.decks[username][deckID] = d
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const recentUsageLimit = 200
+
+// UsageTotals 是一段时间内 AI 用量的累计值。
+type UsageTotals struct {
+	Tests            int64   `json:"tests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (t *UsageTotals) add(other UsageTotals) {
+	t.Tests += other.Tests
+	t.PromptTokens += other.PromptTokens
+	t.CompletionTokens += other.CompletionTokens
+	t.TotalTokens += other.TotalTokens
+	t.Cost += other.Cost
+}
+
+// UsageRecord 是一次测试的 AI 用量。UserKey 为 X-Auth-Token 的摘要，不保存原始令牌。
+type UsageRecord struct {
+	Time    time.Time `json:"time"`
+	UserKey string    `json:"user_key"`
+	PaperID string    `json:"paper_id"`
+	UsageTotals
+}
+
+// DailyUsage 是某一天的用量，Users 按 UserKey 拆分。
+type DailyUsage struct {
+	Date  string                  `json:"date"`
+	Total UsageTotals             `json:"total"`
+	Users map[string]*UsageTotals `json:"users"`
+}
+
+// UsageRepository 按 天 -> 用户 聚合 AI 用量并持久化到 JSON 文件；最近的明细只保存在内存中。
+type UsageRepository struct {
+	filePath string
+	mu       sync.RWMutex
+	daily    map[string]map[string]*UsageTotals
+	recent   []UsageRecord
+	logger   *slog.Logger
+}
+
+func NewUsageRepository(filePath string, logger *slog.Logger) (*UsageRepository, error) {
+	repo := &UsageRepository{
+		filePath: filePath,
+		daily:    make(map[string]map[string]*UsageTotals),
+		logger:   logger.With("component", "usage"),
+	}
+	byteValue, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(byteValue) > 0 {
+		if err := json.Unmarshal(byteValue, &repo.daily); err != nil {
+			repo.logger.Error("AI用量记录加载失败: 解析JSON错误", "error", err)
+			return nil, err
+		}
+	}
+	repo.logger.Info("AI用量仓库已初始化", "path", filePath, "days", len(repo.daily))
+	return repo, nil
+}
+
+func usageDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func (r *UsageRepository) persist() error {
+	byteValue, err := json.MarshalIndent(r.daily, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(r.filePath, byteValue, 0644); err != nil {
+		r.logger.Error("AI用量持久化失败: 写入文件错误", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *UsageRepository) Record(record UsageRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	date := usageDate(record.Time)
+	users, ok := r.daily[date]
+	if !ok {
+		users = make(map[string]*UsageTotals)
+		r.daily[date] = users
+	}
+	totals, ok := users[record.UserKey]
+	if !ok {
+		totals = &UsageTotals{}
+		users[record.UserKey] = totals
+	}
+	totals.add(record.UsageTotals)
+
+	r.recent = append(r.recent, record)
+	if len(r.recent) > recentUsageLimit {
+		r.recent = r.recent[len(r.recent)-recentUsageLimit:]
+	}
+	return r.persist()
+}
+
+// Day 返回某天的总用量；userKey 非空时只统计该用户。
+func (r *UsageRepository) Day(day time.Time, userKey string) UsageTotals {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sumLocked(usageDate(day), userKey)
+}
+
+// Month 返回某天所在月份的总用量。
+func (r *UsageRepository) Month(day time.Time, userKey string) UsageTotals {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	prefix := day.Format("2006-01-")
+	var totals UsageTotals
+	for date := range r.daily {
+		if strings.HasPrefix(date, prefix) {
+			sum := r.sumLocked(date, userKey)
+			totals.add(sum)
+		}
+	}
+	return totals
+}
+
+func (r *UsageRepository) sumLocked(date, userKey string) UsageTotals {
+	var totals UsageTotals
+	for key, userTotals := range r.daily[date] {
+		if userKey == "" || key == userKey {
+			totals.add(*userTotals)
+		}
+	}
+	return totals
+}
+
+// Days 返回最近 days 天 (含今天) 的按日用量，按日期倒序。
+func (r *UsageRepository) Days(now time.Time, days int) []DailyUsage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]DailyUsage, 0, days)
+	for i := 0; i < days; i++ {
+		date := usageDate(now.AddDate(0, 0, -i))
+		users := make(map[string]*UsageTotals)
+		for key, totals := range r.daily[date] {
+			copied := *totals
+			users[key] = &copied
+		}
+		result = append(result, DailyUsage{Date: date, Total: r.sumLocked(date, ""), Users: users})
+	}
+	return result
+}
+
+// Recent 返回最近的用量明细，最新的在前。
+func (r *UsageRepository) Recent(limit int) []UsageRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := make([]UsageRecord, len(r.recent))
+	copy(records, r.recent)
+	sort.Slice(records, func(i, j int) bool { return records[i].Time.After(records[j].Time) })
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// WordEntry 是题库中的一个单词及其释义。
+type WordEntry struct {
+	Word       string `json:"word"`
+	Definition string `json:"definition"`
+}
+
+// trieNode 是按小写字母建立的前缀树节点。count 为子树中的单词数，用于直接得到前缀匹配总数。
+type trieNode struct {
+	b        byte
+	children []*trieNode // 按 b 升序
+	words    []int       // 以该节点结尾的单词 (大小写不同的单词共用一个节点)
+	count    int
+}
+
+func (n *trieNode) child(b byte, create bool) *trieNode {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].b >= b })
+	if i < len(n.children) && n.children[i].b == b {
+		return n.children[i]
+	}
+	if !create {
+		return nil
+	}
+	c := &trieNode{b: b}
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = c
+	return c
+}
+
+// collect 按字典序遍历子树，跳过前 skip 个单词，最多收集 limit 个。返回剩余需要跳过的数量。
+func (n *trieNode) collect(skip, limit int, out *[]int) int {
+	if len(*out) >= limit {
+		return skip
+	}
+	if skip >= n.count {
+		return skip - n.count
+	}
+	for _, id := range n.words {
+		if skip > 0 {
+			skip--
+			continue
+		}
+		if len(*out) >= limit {
+			return 0
+		}
+		*out = append(*out, id)
+	}
+	for _, c := range n.children {
+		skip = c.collect(skip, limit, out)
+	}
+	return skip
+}
+
+// wordIndex 是题库加载时建立的检索结构：单词前缀树和中文释义的倒排索引。加载后只读。
+type wordIndex struct {
+	entries []WordEntry // 按单词排序，下标即单词 ID
+	trie    *trieNode
+	// postings 把释义中出现的每个汉字映射到包含它的单词 ID (升序)
+	postings map[rune][]int
+}
+
+func newWordIndex(wordToDefinition map[string]string) *wordIndex {
+	idx := &wordIndex{
+		entries:  make([]WordEntry, 0, len(wordToDefinition)),
+		trie:     &trieNode{},
+		postings: make(map[rune][]int),
+	}
+	for word, definition := range wordToDefinition {
+		idx.entries = append(idx.entries, WordEntry{Word: word, Definition: definition})
+	}
+	sort.Slice(idx.entries, func(i, j int) bool {
+		li, lj := strings.ToLower(idx.entries[i].Word), strings.ToLower(idx.entries[j].Word)
+		if li != lj {
+			return li < lj
+		}
+		return idx.entries[i].Word < idx.entries[j].Word
+	})
+
+	for id, e := range idx.entries {
+		node := idx.trie
+		node.count++
+		key := strings.ToLower(e.Word)
+		for i := 0; i < len(key); i++ {
+			node = node.child(key[i], true)
+			node.count++
+		}
+		node.words = append(node.words, id)
+
+		seen := make(map[rune]bool)
+		for _, r := range e.Definition {
+			if unicode.Is(unicode.Han, r) && !seen[r] {
+				seen[r] = true
+				idx.postings[r] = append(idx.postings[r], id)
+			}
+		}
+	}
+	return idx
+}
+
+func (idx *wordIndex) resolve(ids []int) []WordEntry {
+	entries := make([]WordEntry, len(ids))
+	for i, id := range ids {
+		entries[i] = idx.entries[id]
+	}
+	return entries
+}
+
+// prefix 返回以 prefix 开头 (不区分大小写) 的单词，按字典序分页，以及匹配总数。
+func (idx *wordIndex) prefix(prefix string, offset, limit int) ([]WordEntry, int) {
+	node := idx.trie
+	key := strings.ToLower(prefix)
+	for i := 0; i < len(key) && node != nil; i++ {
+		node = node.child(key[i], false)
+	}
+	if node == nil {
+		return []WordEntry{}, 0
+	}
+	ids := make([]int, 0, limit)
+	node.collect(offset, limit, &ids)
+	return idx.resolve(ids), node.count
+}
+
+// intersect 求两个升序列表的交集。
+func intersect(a, b []int) []int {
+	out := make([]int, 0, min(len(a), len(b)))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// meaning 返回释义中包含 query 的单词。先用倒排索引取包含 query 中所有汉字的单词，
+// 再逐个确认释义包含完整的 query。释义中有与 query 完全相同的义项的单词排在前面，其余按字典序。
+func (idx *wordIndex) meaning(query string, offset, limit int) ([]WordEntry, int) {
+	var candidates []int
+	first := true
+	for _, r := range query {
+		if !unicode.Is(unicode.Han, r) {
+			continue
+		}
+		if first {
+			candidates, first = idx.postings[r], false
+		} else {
+			candidates = intersect(candidates, idx.postings[r])
+		}
+		if len(candidates) == 0 {
+			return []WordEntry{}, 0
+		}
+	}
+	if first {
+		// query 中没有汉字，无法使用索引
+		return []WordEntry{}, 0
+	}
+
+	var exact, partial []int
+	for _, id := range candidates {
+		definition := idx.entries[id].Definition
+		if !strings.Contains(definition, query) {
+			continue
+		}
+		if hasMeaning(definition, query) {
+			exact = append(exact, id)
+		} else {
+			partial = append(partial, id)
+		}
+	}
+	matched := append(exact, partial...)
+	total := len(matched)
+	if offset >= total {
+		return []WordEntry{}, total
+	}
+	return idx.resolve(matched[offset:min(offset+limit, total)]), total
+}
+
+// hasMeaning 判断释义中是否有与 meaning 完全相同的义项 (去掉词性标记后)。
+func hasMeaning(definition, meaning string) bool {
+	for _, token := range strings.Fields(definition) {
+		if i := strings.LastIndex(token, "."); i >= 0 {
+			token = token[i+1:]
+		}
+		if token == meaning {
+			return true
+		}
+	}
+	return false
+}
+
+// sample 返回随机排列中第 offset 个起的 n 个单词。排列由 r 决定，同一种子的不同 offset
+// 得到的是同一排列的不同片段，互不重复。
+func (idx *wordIndex) sample(offset, n int, r *rand.Rand) []WordEntry {
+	end := min(offset+n, len(idx.entries))
+	if offset >= end {
+		return []WordEntry{}
+	}
+	// 部分 Fisher-Yates 洗牌：只交换前 end 个位置，被交换的位置记录在 map 中
+	swapped := make(map[int]int, end)
+	ids := make([]int, end)
+	for i := 0; i < end; i++ {
+		j := i + r.IntN(len(idx.entries)-i)
+		vi, ok := swapped[i]
+		if !ok {
+			vi = i
+		}
+		vj, ok := swapped[j]
+		if !ok {
+			vj = j
+		}
+		ids[i] = vj
+		swapped[j] = vi
+	}
+	return idx.resolve(ids[offset:])
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testDefinitions = map[string]string{
+	"abandon":  "vt.放弃 抛弃",
+	"ability":  "n.能力 才能",
+	"able":     "adj.能够的 有能力的",
+	"Abroad":   "adv.在国外",
+	"absorb":   "vt.吸收",
+	"career":   "n.职业 生涯",
+	"carry":    "vt.携带 运送",
+	"capacity": "n.容量 能力",
+}
+
+func words(entries []WordEntry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Word
+	}
+	return out
+}
+
+func TestWordIndexPrefix(t *testing.T) {
+	idx := newWordIndex(testDefinitions)
+	tests := []struct {
+		name          string
+		prefix        string
+		offset, limit int
+		want          []string
+		wantTotal     int
+	}{
+		{name: "按字典序返回", prefix: "ab", limit: 10, want: []string{"abandon", "ability", "able", "Abroad", "absorb"}, wantTotal: 5},
+		{name: "不区分大小写", prefix: "ABR", limit: 10, want: []string{"Abroad"}, wantTotal: 1},
+		{name: "分页", prefix: "ab", offset: 1, limit: 2, want: []string{"ability", "able"}, wantTotal: 5},
+		{name: "offset 超出范围", prefix: "ab", offset: 5, limit: 2, want: []string{}, wantTotal: 5},
+		{name: "完整单词也是前缀", prefix: "able", limit: 10, want: []string{"able"}, wantTotal: 1},
+		{name: "没有匹配", prefix: "xyz", limit: 10, want: []string{}, wantTotal: 0},
+		{name: "空前缀匹配全部", prefix: "", limit: 3, want: []string{"abandon", "ability", "able"}, wantTotal: len(testDefinitions)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total := idx.prefix(tt.prefix, tt.offset, tt.limit)
+			if !reflect.DeepEqual(words(got), tt.want) {
+				t.Errorf("words = %v, want %v", words(got), tt.want)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestWordIndexMeaning(t *testing.T) {
+	idx := newWordIndex(testDefinitions)
+	tests := []struct {
+		name          string
+		query         string
+		offset, limit int
+		want          []string
+		wantTotal     int
+	}{
+		{name: "完全相同的义项排在前面", query: "能力", limit: 10, want: []string{"ability", "capacity", "able"}, wantTotal: 3},
+		{name: "匹配义项的一部分", query: "生", limit: 10, want: []string{"career"}, wantTotal: 1},
+		{name: "汉字都出现但不连续时不匹配", query: "放收", limit: 10, want: []string{}, wantTotal: 0},
+		{name: "分页", query: "能力", offset: 2, limit: 10, want: []string{"able"}, wantTotal: 3},
+		{name: "没有汉字", query: "ability", limit: 10, want: []string{}, wantTotal: 0},
+		{name: "空查询", query: "", limit: 10, want: []string{}, wantTotal: 0},
+		{name: "没有匹配", query: "飞机", limit: 10, want: []string{}, wantTotal: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total := idx.meaning(tt.query, tt.offset, tt.limit)
+			if !reflect.DeepEqual(words(got), tt.want) {
+				t.Errorf("words = %v, want %v", words(got), tt.want)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestWordIndexSample(t *testing.T) {
+	idx := newWordIndex(testDefinitions)
+	seed := func() *rand.Rand { return rand.New(rand.NewPCG(7, 7)) }
+
+	all := idx.sample(0, len(testDefinitions), seed())
+	if !reflect.DeepEqual(words(idx.sample(0, 3, seed())), words(all[:3])) {
+		t.Errorf("相同种子的结果不一致")
+	}
+
+	// 同一种子下逐页读取应当得到完整排列，不重复也不遗漏
+	var paged []WordEntry
+	for offset := 0; offset < len(testDefinitions); offset += 3 {
+		paged = append(paged, idx.sample(offset, 3, seed())...)
+	}
+	if !reflect.DeepEqual(words(paged), words(all)) {
+		t.Errorf("分页结果 = %v, want %v", words(paged), words(all))
+	}
+	seen := make(map[string]bool)
+	for _, w := range words(all) {
+		if seen[w] {
+			t.Errorf("单词 %s 重复出现", w)
+		}
+		seen[w] = true
+	}
+	if len(seen) != len(testDefinitions) {
+		t.Errorf("抽到 %d 个单词, want %d", len(seen), len(testDefinitions))
+	}
+
+	if got := idx.sample(len(testDefinitions), 3, seed()); len(got) != 0 {
+		t.Errorf("offset 超出范围时应返回空列表, got %v", words(got))
+	}
+}
+
+func writeWordDatabase(t *testing.T, path string, definitions map[string]string) {
+	t.Helper()
+	meanings := make(map[string]string, len(definitions))
+	for word, definition := range definitions {
+		meanings[definition] = word
+	}
+	byteValue, err := json.Marshal(wordData{WordToDefinition: definitions, MeaningToWord: meanings})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, byteValue, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWordRepositoryReloadRebuildsIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "database.json")
+	writeWordDatabase(t, path, testDefinitions)
+	repo, err := NewWordRepository(path, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, total := repo.SearchPrefix("car", 0, 10); total != 2 {
+		t.Fatalf("重新加载前 car 前缀匹配 %d 个, want 2", total)
+	}
+
+	writeWordDatabase(t, path, map[string]string{
+		"cargo":   "n.货物",
+		"ability": "n.本领",
+	})
+	if _, _, err := repo.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, total := repo.SearchPrefix("car", 0, 10); total != 1 || !reflect.DeepEqual(words(got), []string{"cargo"}) {
+		t.Errorf("car 前缀 = %v (total %d), want [cargo]", words(got), total)
+	}
+	if got, total := repo.SearchMeaning("本领", 0, 10); total != 1 || !reflect.DeepEqual(words(got), []string{"ability"}) {
+		t.Errorf("释义 本领 = %v (total %d), want [ability]", words(got), total)
+	}
+	if _, total := repo.SearchMeaning("能力", 0, 10); total != 0 {
+		t.Errorf("重新加载后旧释义仍能搜到 %d 个单词", total)
+	}
+	if _, total := repo.Sample(0, 10, rand.New(rand.NewPCG(1, 1))); total != 2 {
+		t.Errorf("Sample total = %d, want 2", total)
+	}
+
+	// 新文件无效时保留旧数据
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := repo.Reload(); err == nil {
+		t.Fatal("期望加载空题库失败")
+	}
+	if _, total := repo.SearchPrefix("cargo", 0, 10); total != 1 {
+		t.Errorf("加载失败后 cargo 前缀匹配 %d 个, want 1", total)
+	}
+}
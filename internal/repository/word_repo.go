@@ -3,33 +3,124 @@ package repository
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-type WordRepository struct {
+// wordData 是 database.json 的内容及加载时建立的检索索引。加载后只读，重新加载时整体替换。
+type wordData struct {
 	WordToDefinition map[string]string `json:"wordToDefinition"`
 	MeaningToWord    map[string]string `json:"meaningToWord"`
+	version          uint64
+	index            *wordIndex
+}
+
+// WordRepository 是基础题库。数据通过原子指针持有，Reload 在后台解析新文件后一次性替换，
+// 正在进行的查询继续使用旧数据，不会被阻塞。
+type WordRepository struct {
+	jsonPath string
+	logger   *slog.Logger
+	data     atomic.Pointer[wordData]
+	reloadMu sync.Mutex
+}
+
+func NewWordRepository(jsonPath string, logger *slog.Logger) (*WordRepository, error) {
+	repo := &WordRepository{jsonPath: jsonPath, logger: logger.With("component", "word_repo")}
+	if _, _, err := repo.Reload(); err != nil {
+		return nil, err
+	}
+	return repo, nil
 }
 
-func NewWordRepository(jsonPath string) (*WordRepository, error) {
-	fmt.Println("正在从JSON文件加载题库...")
-	byteValue, err := os.ReadFile(jsonPath)
+// Reload 重新读取 JSON 文件并原子地替换题库，返回新的单词数和释义数。失败时保留旧数据。
+func (r *WordRepository) Reload() (words, meanings int, err error) {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	r.logger.Info("正在从JSON文件加载题库", "path", r.jsonPath)
+	byteValue, err := os.ReadFile(r.jsonPath)
 	if err != nil {
-		return nil, fmt.Errorf("无法读取JSON文件 '%s': %w", jsonPath, err)
+		return 0, 0, fmt.Errorf("无法读取JSON文件 '%s': %w", r.jsonPath, err)
+	}
+	var data wordData
+	if err := json.Unmarshal(byteValue, &data); err != nil {
+		return 0, 0, fmt.Errorf("解析JSON数据失败: %w", err)
+	}
+	if len(data.WordToDefinition) == 0 || len(data.MeaningToWord) == 0 {
+		return 0, 0, fmt.Errorf("题库文件 '%s' 不包含任何词条", r.jsonPath)
 	}
-	var repo WordRepository
-	if err := json.Unmarshal(byteValue, &repo); err != nil {
-		return nil, fmt.Errorf("解析JSON数据失败: %w", err)
+
+	data.index = newWordIndex(data.WordToDefinition)
+	if current := r.data.Load(); current != nil {
+		data.version = current.version + 1
 	}
+	r.data.Store(&data)
+	r.logger.Info("题库加载完成", "words", len(data.WordToDefinition), "meanings", len(data.MeaningToWord))
+	return len(data.WordToDefinition), len(data.MeaningToWord), nil
+}
+
+// Size 返回单词数和中文释义数。
+func (r *WordRepository) Size() (words, meanings int) {
+	data := r.data.Load()
+	return len(data.WordToDefinition), len(data.MeaningToWord)
+}
 
-	fmt.Printf("题库加载完成，共加载 %d 个英文单词和 %d 个中文释义映射。\n", len(repo.WordToDefinition), len(repo.MeaningToWord))
-	return &repo, nil
+// Definitions 返回当前的 单词 -> 释义 映射及其版本号，版本号在每次重新加载后递增。
+// 返回的 map 与题库共享，调用方不能修改。
+func (r *WordRepository) Definitions() (map[string]string, uint64) {
+	data := r.data.Load()
+	return data.WordToDefinition, data.version
 }
 
 func (r *WordRepository) FindDefinitionByWord(word string) string {
-	return r.WordToDefinition[word]
+	return r.data.Load().WordToDefinition[word]
 }
 
 func (r *WordRepository) FindWordByMeaning(meaning string) string {
-	return r.MeaningToWord[meaning]
+	return r.data.Load().MeaningToWord[meaning]
+}
+
+// LookupDefinition 查找单词释义，精确匹配失败时再尝试去除首尾标点和转为小写。
+func (r *WordRepository) LookupDefinition(word string) (string, string, bool) {
+	data := r.data.Load()
+	candidates := []string{word, strings.TrimSpace(strings.TrimRight(word, ". "))}
+	candidates = append(candidates, strings.ToLower(candidates[1]))
+	for _, candidate := range candidates {
+		if definition, ok := data.WordToDefinition[candidate]; ok {
+			return candidate, definition, true
+		}
+	}
+	return "", "", false
+}
+
+// LookupWord 按中文释义查找单词，精确匹配失败时再尝试去除首尾标点。
+func (r *WordRepository) LookupWord(meaning string) (string, bool) {
+	data := r.data.Load()
+	for _, candidate := range []string{meaning, strings.TrimSpace(strings.TrimRight(meaning, ". "))} {
+		if word, ok := data.MeaningToWord[candidate]; ok {
+			return word, true
+		}
+	}
+	return "", false
+}
+
+// SearchPrefix 返回以 prefix 开头 (不区分大小写) 的单词，按字典序分页，以及匹配总数。
+func (r *WordRepository) SearchPrefix(prefix string, offset, limit int) ([]WordEntry, int) {
+	return r.data.Load().index.prefix(prefix, offset, limit)
+}
+
+// SearchMeaning 返回释义中包含 query 的单词及匹配总数，有完全相同义项的单词排在前面。
+// query 中必须包含汉字。
+func (r *WordRepository) SearchMeaning(query string, offset, limit int) ([]WordEntry, int) {
+	return r.data.Load().index.meaning(query, offset, limit)
+}
+
+// Sample 返回由 rng 决定的随机排列中第 offset 个起的 n 个单词，以及题库单词总数。
+func (r *WordRepository) Sample(offset, n int, rng *rand.Rand) ([]WordEntry, int) {
+	index := r.data.Load().index
+	return index.sample(offset, n, rng), len(index.entries)
 }
@@ -2,26 +2,120 @@ package router
 
 import (
 	"HDU-Auto-Word-Ans-Online-Backend/internal/api"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/api/response"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/apiauth"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/middleware"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/session"
+	"log/slog"
+	"net/http"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func SetupRouter(examHandler *api.ExamHandler, allowedOrigins []string) *gin.Engine {
-	r := gin.Default()
+type Options struct {
+	AllowedOrigins *middleware.AllowedOrigins
+	AdminToken     string
+	// Auth 校验 /api/v1 的 API Key 或 JWT，Quota 记录每个调用方当天的测试次数
+	Auth  *apiauth.Authenticator
+	Quota *apiauth.QuotaTracker
+	// RateLimiter 限制 /api/v1 的全局请求速率，InFlight 保证同一用户同时只有一个测试
+	RateLimiter    *middleware.RateLimiter
+	InFlight       *middleware.InFlightGuard
+	Sessions       *session.Manager
+	MetricsEnabled bool
+	// TracingService 不为空时为每个请求记录 OTel span，值作为 span 的 server 名称
+	TracingService string
+	Logger         *slog.Logger
+}
+
+func SetupRouter(examHandler *api.ExamHandler, authHandler *api.AuthHandler, studyHandler *api.StudyHandler, quizHandler *api.QuizHandler, practiceHandler *api.PracticeHandler, notebookHandler *api.NotebookHandler, historyHandler *api.HistoryHandler, paperHandler *api.PaperHandler, wordHandler *api.WordHandler, adminHandler *api.AdminHandler, healthHandler *api.HealthHandler, opts Options) *gin.Engine {
+	r := gin.New()
+	if opts.TracingService != "" {
+		r.Use(otelgin.Middleware(opts.TracingService, otelgin.WithFilter(func(req *http.Request) bool {
+			switch req.URL.Path {
+			case "/metrics", "/healthz", "/readyz":
+				return false
+			}
+			return true
+		})))
+	}
+	r.Use(middleware.RequestID(), middleware.Logger(opts.Logger), gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		logging.FromContext(c.Request.Context(), opts.Logger).Error("请求处理发生 panic", "panic", recovered)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternalError, nil)
+	}))
+	r.NoRoute(func(c *gin.Context) {
+		response.Error(c, http.StatusNotFound, response.CodeNotFound, c.Request.URL.Path)
+	})
 
 	config := cors.DefaultConfig()
-	config.AllowOrigins = allowedOrigins
-	config.AllowHeaders = append(config.AllowHeaders, "X-Auth-Token", "Content-Type")
+	config.AllowOriginFunc = opts.AllowedOrigins.Allow
+	config.AllowHeaders = append(config.AllowHeaders, "X-Auth-Token", "X-Session-ID", "X-Admin-Token", "X-API-Key", "X-Request-ID", "Content-Type", "Accept-Language")
+	config.ExposeHeaders = append(config.ExposeHeaders, "X-Request-ID", "X-Session-ID", "X-Quota-Limit", "X-Quota-Remaining")
 	r.Use(cors.New(config))
 
-	apiV1 := r.Group("/api/v1")
+	r.GET("/healthz", healthHandler.LivenessHandler)
+	r.GET("/readyz", healthHandler.ReadinessHandler)
+	if opts.MetricsEnabled {
+		r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
+
+	apiV1 := r.Group("/api/v1", middleware.RateLimit(opts.RateLimiter))
 	{
-		apiV1.POST("/start-test", examHandler.StartTestHandler)
-		apiV1.POST("/login-and-start", examHandler.LoginAndStartTestHandler)
-		apiV1.GET("/health", func(c *gin.Context) {
-			c.JSON(200, gin.H{"status": "UP"})
-		})
+		apiV1.GET("/health", healthHandler.LivenessHandler)
+
+		oneTest := middleware.OneTestPerUser(opts.InFlight)
+
+		// 登录和注销不经过 Session：携带过期 X-Session-ID 的客户端也必须能重新登录
+		login := apiV1.Group("", middleware.APIAuth(opts.Auth))
+		{
+			login.POST("/login-and-start", oneTest, middleware.Quota(opts.Quota), examHandler.LoginAndStartTestHandler)
+			login.POST("/auth/login", authHandler.LoginHandler)
+			login.POST("/auth/logout", authHandler.LogoutHandler)
+		}
+
+		authed := apiV1.Group("", middleware.APIAuth(opts.Auth), middleware.Session(opts.Sessions))
+		{
+			authed.POST("/start-test", oneTest, middleware.Quota(opts.Quota), examHandler.StartTestHandler)
+			authed.GET("/auth/me", authHandler.MeHandler)
+
+			authed.GET("/study/decks", studyHandler.ListDecksHandler)
+			authed.POST("/study/decks", studyHandler.CreateDeckHandler)
+			authed.GET("/study/decks/:deckId", studyHandler.GetDeckHandler)
+			authed.DELETE("/study/decks/:deckId", studyHandler.DeleteDeckHandler)
+			authed.GET("/study/decks/:deckId/due", studyHandler.DueCardsHandler)
+			authed.POST("/study/decks/:deckId/reviews", studyHandler.ReviewHandler)
+
+			authed.GET("/quiz", quizHandler.GenerateQuizHandler)
+			authed.POST("/practice", practiceHandler.StartPracticeHandler)
+			authed.POST("/practice/submit", practiceHandler.SubmitPracticeHandler)
+			authed.GET("/practice/mastery", practiceHandler.MasteryHandler)
+
+			authed.GET("/mistakes", notebookHandler.MistakesHandler)
+			authed.GET("/history", historyHandler.ListHistoryHandler)
+			authed.GET("/history/stats", historyHandler.StatsHandler)
+			authed.GET("/papers/:paperId", paperHandler.ReviewPaperHandler)
+
+			authed.GET("/words", wordHandler.SearchWordsHandler)
+			authed.GET("/words/random", wordHandler.RandomWordsHandler)
+			authed.GET("/words/:word", wordHandler.LookupWordHandler)
+		}
+
+		admin := apiV1.Group("/admin", middleware.Admin(opts.Auth, opts.AdminToken))
+		{
+			admin.GET("/ai-usage", adminHandler.AIUsageHandler)
+			admin.POST("/dictionary/reload", adminHandler.ReloadDictionaryHandler)
+
+			admin.GET("/answer-bank", adminHandler.SearchAnswerBankHandler)
+			admin.GET("/answer-bank/recent", adminHandler.RecentAnswersHandler)
+			admin.GET("/answer-bank/stats", adminHandler.AnswerBankStatsHandler)
+			admin.GET("/answer-bank/:id", adminHandler.GetAnswerHandler)
+			admin.PUT("/answer-bank/:id", adminHandler.UpdateAnswerHandler)
+			admin.DELETE("/answer-bank/:id", adminHandler.DeleteAnswerHandler)
+		}
 	}
 
 	return r
@@ -1,149 +1,379 @@
 package service
 
 import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/metrics"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/prompt"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/tracing"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type AIService struct {
-	BaseURL    string
-	APIKey     string
-	Model      string
-	HttpClient *http.Client
-}
-
-func NewAIService(baseURL, apiKey, model string, timeoutSec int) *AIService {
-	return &AIService{
-		BaseURL: baseURL,
-		APIKey:  apiKey,
-		Model:   model,
-		HttpClient: &http.Client{
-			Timeout: time.Duration(timeoutSec) * time.Second,
-		},
+	prompts  *prompt.Store
+	wordRepo *repository.WordRepository
+	logger   *slog.Logger
+
+	// 以下字段可在运行时通过 Reconfigure / SetVoting 热更新
+	mu               sync.RWMutex
+	provider         AIProvider
+	httpClient       *http.Client
+	votingProviders  []AIProvider
+	votingByExamType map[int]VotingConfig
+}
+
+func NewAIService(baseURL, apiKey, model string, timeoutSec int, prompts *prompt.Store, wordRepo *repository.WordRepository, logger *slog.Logger) *AIService {
+	s := &AIService{
+		logger:   logger,
+		prompts:  prompts,
+		wordRepo: wordRepo,
 	}
+	s.Reconfigure(AIProvider{BaseURL: baseURL, APIKey: apiKey, Model: model}, timeoutSec)
+	return s
 }
 
-func (s *AIService) GetAnswerFromAI(q model.Question) (string, error) {
-	prompt := fmt.Sprintf(`
-你要做的是词义匹配，找到和问题最贴切的选项。
-最终只回答一个被'-'包起来的大写字母作为答案, 例如"-B-"。
-不要包含任何其他解释或文字。
+// Reconfigure 替换默认模型服务和请求超时，已经发出的请求不受影响。
+func (s *AIService) Reconfigure(p AIProvider, timeoutSec int) {
+	client := &http.Client{Timeout: time.Duration(timeoutSec) * time.Second}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provider = p
+	s.httpClient = client
+}
 
-问题: %s
-A. %s
-B. %s
-C. %s
-D. %s`,
-		q.Title, q.AnswerA, q.AnswerB, q.AnswerC, q.AnswerD)
+// ModelName 返回当前默认模型名。
+func (s *AIService) ModelName() string {
+	return s.defaultProvider().Model
+}
 
-	isEnglishQuestion := (q.Title[0] >= 'a' && q.Title[0] <= 'z') || (q.Title[0] >= 'A' && q.Title[0] <= 'Z')
-	var systemPrompt string
-	if isEnglishQuestion {
-		systemPrompt = "你要做的是词义匹配，找到和英文单词最贴切的中文解释"
-	} else {
-		systemPrompt = "你要做的是词义匹配，找到和中文意思最贴切的英语单词"
+func (s *AIService) client() *http.Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.httpClient
+}
+
+// AIProvider 描述一个兼容 OpenAI chat/completions 接口的模型服务。
+type AIProvider struct {
+	BaseURL string `mapstructure:"base_url"`
+	APIKey  string `mapstructure:"api_key"`
+	Model   string `mapstructure:"model"`
+}
+
+// VotingConfig 控制自洽投票模式：同一道题采样 Samples 次，按多数票确定答案。
+// Temperature 为空时不在请求中设置，使用模型服务的默认值。
+type VotingConfig struct {
+	Samples     int      `mapstructure:"samples"`
+	Temperature *float64 `mapstructure:"temperature"`
+}
+
+// VotedAnswer 是投票的结果，Confidence 为得票最多的选项占有效票数的比例。
+// 多个选项并列最多时 Tied 为 true，Confidence 再除以并列的选项数，表示答案并不可靠。
+type VotedAnswer struct {
+	Answer     string         `json:"answer"`
+	Confidence float64        `json:"confidence"`
+	Tied       bool           `json:"tied,omitempty"`
+	Votes      map[string]int `json:"votes"`
+}
+
+func (s *AIService) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, s.logger).With("component", "ai")
+}
+
+func (s *AIService) defaultProvider() AIProvider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.provider
+}
+
+// SetVoting 配置投票模式。providers 为空时仅对默认模型重复采样；
+// examTypes 的 key 为考试类型 (0:自测 1:考试)，Samples <= 1 表示关闭投票。
+func (s *AIService) SetVoting(providers []AIProvider, examTypes map[int]VotingConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.votingProviders = providers
+	s.votingByExamType = examTypes
+}
+
+func (s *AIService) providers() []AIProvider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.votingProviders
+}
+
+// VotingFor 返回指定考试类型的投票配置，未开启时第二个返回值为 false。
+func (s *AIService) VotingFor(examType int) (VotingConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cfg, ok := s.votingByExamType[examType]
+	if !ok || cfg.Samples <= 1 {
+		return VotingConfig{}, false
+	}
+	return cfg, true
+}
+
+// CheckConfig 检查默认模型和投票模型的配置是否完整，不发起网络请求。
+func (s *AIService) CheckConfig() error {
+	providers := append([]AIProvider{s.defaultProvider()}, s.providers()...)
+	for i, p := range providers {
+		name := "ai_service"
+		if i > 0 {
+			name = fmt.Sprintf("ai_service.voting.providers[%d]", i-1)
+		}
+		u, err := url.Parse(p.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s.base_url 不是有效的 http(s) 地址: %q", name, p.BaseURL)
+		}
+		if p.APIKey == "" {
+			return fmt.Errorf("%s.api_key 未配置", name)
+		}
+		if p.Model == "" {
+			return fmt.Errorf("%s.model 未配置", name)
+		}
+	}
+	return nil
+}
+
+// Ping 请求默认服务的 /models 接口，确认服务可达且 API Key 有效。
+func (s *AIService) Ping(ctx context.Context) error {
+	p := s.defaultProvider()
+	req, err := http.NewRequestWithContext(ctx, "GET", p.BaseURL+"/models", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("AI服务返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *AIService) GetAnswerFromAI(ctx context.Context, q model.Question) (string, model.AIUsage, error) {
+	return s.askSingle(ctx, s.defaultProvider(), q, nil)
+}
+
+// VoteAnswerFromAI 对同一道题并发采样 cfg.Samples 次 (在配置的多个服务之间轮换)，
+// 返回多数票答案及一致率。全部采样失败时返回最后一个错误。usage 为所有采样消耗的 token 之和。
+func (s *AIService) VoteAnswerFromAI(ctx context.Context, q model.Question, cfg VotingConfig) (*VotedAnswer, model.AIUsage, error) {
+	providers := s.providers()
+	if len(providers) == 0 {
+		providers = []AIProvider{s.defaultProvider()}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var lastErr error
+	var usage model.AIUsage
+	votes := make(map[string]int)
+	for i := 0; i < cfg.Samples; i++ {
+		wg.Add(1)
+		go func(p AIProvider) {
+			defer wg.Done()
+			answer, sampleUsage, err := s.askSingle(ctx, p, q, cfg.Temperature)
+			mu.Lock()
+			defer mu.Unlock()
+			usage.Add(sampleUsage)
+			if err != nil {
+				lastErr = err
+				return
+			}
+			votes[answer]++
+		}(providers[i%len(providers)])
+	}
+	wg.Wait()
+
+	total := 0
+	best := ""
+	tied := 0
+	for _, option := range []string{"A", "B", "C", "D"} {
+		total += votes[option]
+		switch {
+		case votes[option] > votes[best]:
+			best, tied = option, 1
+		case votes[option] > 0 && votes[option] == votes[best]:
+			tied++
+		}
+	}
+	if total == 0 {
+		return nil, usage, fmt.Errorf("AI投票全部失败 (%d 次采样): %w", cfg.Samples, lastErr)
+	}
+
+	return &VotedAnswer{
+		Answer:     best,
+		Confidence: float64(votes[best]) / float64(total) / float64(tied),
+		Tied:       tied > 1,
+		Votes:      votes,
+	}, usage, nil
+}
+
+// promptData 为题目附上本地词库中的相关词条：英文题目和英文选项的释义，
+// 以及中文题目和中文选项对应的单词，帮助模型处理词库中未能精确匹配的题目。
+func (s *AIService) promptData(q model.Question) prompt.SingleData {
+	data := prompt.SingleData{Question: q}
+	if s.wordRepo == nil {
+		return data
+	}
+
+	seen := make(map[string]bool)
+	for _, text := range []string{q.Title, q.AnswerA, q.AnswerB, q.AnswerC, q.AnswerD} {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		var word, definition string
+		var ok bool
+		if utils.IsEnglish(text) {
+			word, definition, ok = s.wordRepo.LookupDefinition(text)
+		} else if word, ok = s.wordRepo.LookupWord(text); ok {
+			definition = s.wordRepo.FindDefinitionByWord(word)
+			if definition == "" {
+				definition = text
+			}
+		}
+		if !ok || seen[word] {
+			continue
+		}
+		seen[word] = true
+		data.Dictionary = append(data.Dictionary, prompt.DictEntry{Word: word, Definition: definition})
+	}
+	return data
+}
+
+func (s *AIService) batchPromptData(questions []model.Question) prompt.BatchData {
+	data := prompt.BatchData{Count: len(questions)}
+	for _, q := range questions {
+		data.Questions = append(data.Questions, s.promptData(q))
+	}
+	return data
+}
+
+func (s *AIService) askSingle(ctx context.Context, p AIProvider, q model.Question, temperature *float64) (answer string, usage model.AIUsage, err error) {
+	systemPrompt, userPrompt, err := s.prompts.Render(prompt.SingleKind(q), p.Model, s.promptData(q))
+	if err != nil {
+		return "", model.AIUsage{}, err
 	}
 
 	payload := model.AIChatRequest{
-		Model: s.Model,
+		Model: p.Model,
 		Messages: []model.Message{
 			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: prompt},
+			{Role: "user", Content: userPrompt},
 		},
+		Temperature: temperature,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
-	log.Printf("--- Sending SINGLE request to AI ---\n")
+	s.log(ctx).Debug("发送单题AI请求", "model", p.Model, "title", q.Title)
 
-	req, _ := http.NewRequest("POST", s.BaseURL+"/chat/completions", bytes.NewBuffer(payloadBytes))
+	start := time.Now()
+	outcome := "error"
+	ctx, span := tracing.Start(ctx, "ai.chat", attribute.String("ai.model", p.Model), attribute.String("ai.mode", "single"))
+	defer func() {
+		metrics.ObserveAI(p.Model, "single", outcome, start)
+		span.SetAttributes(attribute.String("ai.outcome", outcome), attribute.Int64("ai.total_tokens", usage.TotalTokens))
+		tracing.End(span, err)
+	}()
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/chat/completions", bytes.NewBuffer(payloadBytes))
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
 
-	resp, err := s.HttpClient.Do(req)
+	resp, err := s.client().Do(req)
 	if err != nil {
-		return "", err
+		return "", model.AIUsage{}, err
 	}
 	defer resp.Body.Close()
 
 	var aiResponse model.AIChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&aiResponse); err != nil {
-		return "", err
+		return "", model.AIUsage{}, err
 	}
 
 	if len(aiResponse.Choices) > 0 {
 		content := aiResponse.Choices[0].Message.Content
-		log.Printf("--- Received SINGLE response from AI ---\n%s\n--------------------------------------\n", content)
+		s.log(ctx).Debug("收到单题AI响应", "model", p.Model, "content", logging.Truncate(content, 200))
 		re := regexp.MustCompile(`-([A-D])-`)
 		matches := re.FindStringSubmatch(content)
 		if len(matches) > 1 {
-			return matches[1], nil
+			outcome = "success"
+			return matches[1], aiResponse.Usage, nil
 		}
 	}
 
-	return "", fmt.Errorf("AI未能按预期格式返回答案")
+	outcome = "bad_format"
+	return "", aiResponse.Usage, fmt.Errorf("AI未能按预期格式返回答案")
 }
 
-func (s *AIService) BatchGetAnswersFromAI(questions []model.Question) ([]string, error) {
-	var promptBuilder strings.Builder
-	promptBuilder.WriteString("你需要一次性解决以下所有词义匹配问题。\n")
-	promptBuilder.WriteString("请严格按照问题的顺序，在独立的一行中只回答一个大写字母选项 (A, B, C, 或 D)。\n")
-	promptBuilder.WriteString(fmt.Sprintf("总共有 %d 个问题，所以你的回答也应该恰好是 %d 行，每行只有一个字母。\n\n", len(questions), len(questions)))
-
-	for i, q := range questions {
-		// 不再发送问题ID，只按顺序提问
-		promptBuilder.WriteString(fmt.Sprintf("--- 问题 %d ---\n", i+1))
-		promptBuilder.WriteString(fmt.Sprintf("题目: %s\n", q.Title))
-		promptBuilder.WriteString(fmt.Sprintf("A. %s\n", q.AnswerA))
-		promptBuilder.WriteString(fmt.Sprintf("B. %s\n", q.AnswerB))
-		promptBuilder.WriteString(fmt.Sprintf("C. %s\n", q.AnswerC))
-		promptBuilder.WriteString(fmt.Sprintf("D. %s\n\n", q.AnswerD))
+func (s *AIService) BatchGetAnswersFromAI(ctx context.Context, questions []model.Question) (answers []string, usage model.AIUsage, err error) {
+	p := s.defaultProvider()
+	systemPrompt, userPrompt, err := s.prompts.Render(prompt.KindBatch, p.Model, s.batchPromptData(questions))
+	if err != nil {
+		return nil, model.AIUsage{}, err
 	}
 
-	systemPrompt := "你是一个高效的英语词义匹配助手，你需要根据指令批量处理问题并严格按顺序、按指定格式返回结果。"
-
 	payload := model.AIChatRequest{
-		Model: s.Model,
+		Model: p.Model,
 		Messages: []model.Message{
 			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: promptBuilder.String()},
+			{Role: "user", Content: userPrompt},
 		},
 	}
 
 	payloadBytes, _ := json.Marshal(payload)
-	log.Printf("--- Sending SINGLE request to AI ---\n")
-	req, _ := http.NewRequest("POST", s.BaseURL+"/chat/completions", bytes.NewBuffer(payloadBytes))
+	s.log(ctx).Debug("发送批量AI请求", "model", p.Model, "questions", len(questions))
+
+	start := time.Now()
+	outcome := "error"
+	ctx, span := tracing.Start(ctx, "ai.chat", attribute.String("ai.model", p.Model), attribute.String("ai.mode", "batch"), attribute.Int("ai.questions", len(questions)))
+	defer func() {
+		metrics.ObserveAI(p.Model, "batch", outcome, start)
+		span.SetAttributes(attribute.String("ai.outcome", outcome), attribute.Int64("ai.total_tokens", usage.TotalTokens))
+		tracing.End(span, err)
+	}()
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/chat/completions", bytes.NewBuffer(payloadBytes))
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
 
-	resp, err := s.HttpClient.Do(req)
+	resp, err := s.client().Do(req)
 	if err != nil {
-		return nil, err
+		return nil, model.AIUsage{}, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取AI响应体失败: %w", err)
+		return nil, model.AIUsage{}, fmt.Errorf("读取AI响应体失败: %w", err)
 	}
 	var aiResponse model.AIChatResponse
 	if err := json.Unmarshal(bodyBytes, &aiResponse); err != nil {
-		// 如果JSON解析失败，打印原始的响应体，这有助于发现问题
-		log.Printf("!!! 错误: 解析AI批量响应JSON失败。原始响应体如下: !!!\n%s\n!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!\n", string(bodyBytes))
-		return nil, fmt.Errorf("解析AI批量响应JSON失败: %w", err)
+		// 如果JSON解析失败，记录响应体片段，这有助于发现问题
+		s.log(ctx).Error("解析AI批量响应JSON失败", "error", err, "body", logging.Truncate(string(bodyBytes), 500))
+		return nil, model.AIUsage{}, fmt.Errorf("解析AI批量响应JSON失败: %w", err)
 	}
 
-	var answers []string
 	if len(aiResponse.Choices) > 0 {
 		content := aiResponse.Choices[0].Message.Content
-		log.Printf("--- Received SINGLE response from AI ---\n%s\n--------------------------------------\n", content)
+		s.log(ctx).Debug("收到批量AI响应", "model", p.Model, "content", logging.Truncate(content, 500))
 		re := regexp.MustCompile(`(?m)^([A-D])$`)
 
 		matches := re.FindAllStringSubmatch(content, -1)
@@ -153,13 +383,15 @@ func (s *AIService) BatchGetAnswersFromAI(questions []model.Question) ([]string,
 			}
 		}
 	} else {
-		log.Printf("--- Received BATCH response from AI (but choices array is empty) ---\nRaw Body: %s\n---------------------------------------\n", string(bodyBytes))
+		s.log(ctx).Warn("AI批量响应的 choices 为空", "body", logging.Truncate(string(bodyBytes), 500))
 	}
 
 	if len(answers) != len(questions) {
-		fmt.Printf("警告: AI返回的答案数量 (%d) 与问题数量 (%d) 不匹配。\n", len(answers), len(questions))
-		return nil, fmt.Errorf("AI返回的答案数量 (%d) 与问题数量 (%d) 不匹配", len(answers), len(questions))
+		outcome = "bad_format"
+		s.log(ctx).Warn("AI返回的答案数量与问题数量不匹配", "answers", len(answers), "questions", len(questions))
+		return nil, aiResponse.Usage, fmt.Errorf("AI返回的答案数量 (%d) 与问题数量 (%d) 不匹配", len(answers), len(questions))
 	}
 
-	return answers, nil
+	outcome = "success"
+	return answers, aiResponse.Usage, nil
 }
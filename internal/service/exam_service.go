@@ -2,16 +2,24 @@ package service
 
 import (
 	"HDU-Auto-Word-Ans-Online-Backend/internal/client"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/metrics"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
 	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/tracing"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ExamService struct {
@@ -19,17 +27,40 @@ type ExamService struct {
 	aiService      *AIService
 	wordRepo       *repository.WordRepository
 	answerBankRepo *repository.AnswerBankRepository
+	paperRepo      *repository.PaperRepository
+	historyRepo    *repository.HistoryRepository
+	usageService   *UsageService
+	logger         *slog.Logger
 }
 
-func NewExamService(hduClient *client.HduApiClient, aiService *AIService, wordRepo *repository.WordRepository, answerBankRepo *repository.AnswerBankRepository) *ExamService {
+func NewExamService(hduClient *client.HduApiClient, aiService *AIService, wordRepo *repository.WordRepository, answerBankRepo *repository.AnswerBankRepository, paperRepo *repository.PaperRepository, historyRepo *repository.HistoryRepository, usageService *UsageService, logger *slog.Logger) *ExamService {
 	return &ExamService{
 		hduClient:      hduClient,
 		aiService:      aiService,
 		wordRepo:       wordRepo,
 		answerBankRepo: answerBankRepo,
+		paperRepo:      paperRepo,
+		historyRepo:    historyRepo,
+		usageService:   usageService,
+		logger:         logger,
 	}
 }
 
+// TestResult 是一次自动化测试的结果。AIConfidence 仅在投票模式下记录每道AI题目的一致率。
+type TestResult struct {
+	Message      string         `json:"message"`
+	AIConfidence []AIConfidence `json:"ai_confidence,omitempty"`
+}
+
+type AIConfidence struct {
+	PaperDetailID string         `json:"paper_detail_id"`
+	Title         string         `json:"title"`
+	Answer        string         `json:"answer"`
+	Confidence    float64        `json:"confidence"`
+	Tied          bool           `json:"tied,omitempty"`
+	Votes         map[string]int `json:"votes"`
+}
+
 type answerToModify struct {
 	PaperDetailID string
 	CorrectAnswer string
@@ -45,8 +76,8 @@ func generateQuestionFingerprint(q model.Question) string {
 	return fmt.Sprintf("%s|%s|%s|%s|%s", title, optA, optB, optC, optD)
 }
 
-func (s *ExamService) GetCurrentWeek(xAuthToken string) (int, error) {
-	courseInfo, err := s.hduClient.FetchCurrentWeek(xAuthToken)
+func (s *ExamService) GetCurrentWeek(ctx context.Context, xAuthToken string) (int, error) {
+	courseInfo, err := s.hduClient.FetchCurrentWeek(ctx, xAuthToken)
 	if err != nil {
 		return 0, err
 	}
@@ -58,27 +89,43 @@ func (s *ExamService) GetCurrentWeek(xAuthToken string) (int, error) {
 	return courseInfo.Week, nil
 }
 
-func isEnglish(s string) bool {
-	if len(s) == 0 {
-		return false
+// testOutcome 将测试结果归类为监控指标的 outcome 标签。
+func testOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, client.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, client.ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, client.ErrPaperUnavailable):
+		return "paper_unavailable"
+	case errors.Is(err, client.ErrUpstreamUnavailable):
+		return "upstream_unavailable"
+	default:
+		return "error"
 	}
-	firstChar := rune(s[0])
-	return (firstChar >= 'a' && firstChar <= 'z') || (firstChar >= 'A' && firstChar <= 'Z')
 }
 
-func (s *ExamService) ProcessTest(xAuthToken string, delaySeconds int, week int, examType int, correctCount int) (string, error) {
+// username 为会话中的用户名，不为空时记录测试历史，并保存试卷详情供错题本使用。
+func (s *ExamService) ProcessTest(ctx context.Context, xAuthToken, username string, delaySeconds int, week int, examType int, correctCount int) (result *TestResult, err error) {
+	ctx, span := tracing.Start(ctx, "exam.process_test", attribute.Int("exam.week", week), attribute.Int("exam.type", examType))
+	defer func() {
+		metrics.TestsProcessed.WithLabelValues(strconv.Itoa(examType), testOutcome(err)).Inc()
+		tracing.End(span, err)
+	}()
 	startTime := time.Now()
-	fmt.Println("开始处理新的测试请求...")
+	logger := logging.FromContext(ctx, s.logger).With("component", "exam", "week", week, "exam_type", examType)
+	logger.Info("开始处理新的测试请求")
 
-	paper, err := s.hduClient.GetNewPaper(xAuthToken, week, fmt.Sprintf("%d", examType))
+	paper, err := s.hduClient.GetNewPaper(ctx, xAuthToken, week, fmt.Sprintf("%d", examType))
 
 	if err != nil {
-		if errors.Is(err, client.ErrRateLimited) {
-			return "", err
-		}
-		return "获取试卷失败", err
+		return nil, err
 	}
-	fmt.Printf("成功获取试卷，ID: %s\n", paper.PaperID)
+	logger = logger.With("paper_id", paper.PaperID)
+	span.SetAttributes(attribute.String("exam.paper_id", paper.PaperID), attribute.Int("exam.questions", len(paper.List)))
+	logger.Info("成功获取试卷", "questions", len(paper.List))
 
 	var unsolvedQuestions []model.Question
 	finalAnswers := make(map[string]string)
@@ -86,72 +133,126 @@ func (s *ExamService) ProcessTest(xAuthToken string, delaySeconds int, week int,
 	bankHitCount := 0
 	dbHitCount := 0
 
+	// 第一阶段: 答案银行
+	_, bankSpan := tracing.Start(ctx, "exam.answer_source.bank")
+	var bankMisses []model.Question
 	for _, q := range paper.List {
-		var foundAnswer string
-		fingerprint := generateQuestionFingerprint(q)
-		answer, found := s.answerBankRepo.Query(fingerprint)
-		if found {
-			foundAnswer = answer
+		if answer, found := s.answerBankRepo.Query(generateQuestionFingerprint(q)); found {
+			finalAnswers[q.PaperDetailID] = answer
 			bankHitCount++
 		} else {
-			title := strings.TrimSpace(strings.TrimRight(q.Title, ". "))
-			options := map[string]string{
-				"A": strings.TrimSpace(strings.TrimRight(q.AnswerA, ". ")),
-				"B": strings.TrimSpace(strings.TrimRight(q.AnswerB, ". ")),
-				"C": strings.TrimSpace(strings.TrimRight(q.AnswerC, ". ")),
-				"D": strings.TrimSpace(strings.TrimRight(q.AnswerD, ". ")),
-			}
+			bankMisses = append(bankMisses, q)
+		}
+	}
+	bankSpan.SetAttributes(attribute.Int("exam.hits", bankHitCount))
+	bankSpan.End()
 
-			if isEnglish(title) {
-				fullDefinition := s.wordRepo.FindDefinitionByWord(title)
-				if fullDefinition != "" {
-					for optionKey, optionValue := range options {
-						if strings.Contains(fullDefinition, optionValue) {
-							foundAnswer = optionKey
-							break
-						}
+	// 第二阶段: 本地词库
+	_, dictSpan := tracing.Start(ctx, "exam.answer_source.dictionary")
+	for _, q := range bankMisses {
+		var foundAnswer string
+		title := strings.TrimSpace(strings.TrimRight(q.Title, ". "))
+		options := map[string]string{
+			"A": strings.TrimSpace(strings.TrimRight(q.AnswerA, ". ")),
+			"B": strings.TrimSpace(strings.TrimRight(q.AnswerB, ". ")),
+			"C": strings.TrimSpace(strings.TrimRight(q.AnswerC, ". ")),
+			"D": strings.TrimSpace(strings.TrimRight(q.AnswerD, ". ")),
+		}
+
+		if utils.IsEnglish(title) {
+			fullDefinition := s.wordRepo.FindDefinitionByWord(title)
+			if fullDefinition != "" {
+				for optionKey, optionValue := range options {
+					if strings.Contains(fullDefinition, optionValue) {
+						foundAnswer = optionKey
+						break
 					}
 				}
-			} else {
-				correctWord := s.wordRepo.FindWordByMeaning(title)
-				if correctWord != "" {
-					for optionKey, optionValue := range options {
-						if optionValue == correctWord {
-							foundAnswer = optionKey
-							break
-						}
+			}
+		} else {
+			correctWord := s.wordRepo.FindWordByMeaning(title)
+			if correctWord != "" {
+				for optionKey, optionValue := range options {
+					if optionValue == correctWord {
+						foundAnswer = optionKey
+						break
 					}
 				}
 			}
-
-			if foundAnswer != "" {
-				dbHitCount++
-			}
 		}
 
 		if foundAnswer != "" {
-			mu.Lock()
 			finalAnswers[q.PaperDetailID] = foundAnswer
-			mu.Unlock()
+			dbHitCount++
 		} else {
 			unsolvedQuestions = append(unsolvedQuestions, q)
 		}
 	}
+	dictSpan.SetAttributes(attribute.Int("exam.hits", dbHitCount))
+	dictSpan.End()
 
 	// 统计命中情况
-	fmt.Printf("答案银行命中 %d 题，PDF题库命中 %d 题，有 %d 题待AI解决。\n", bankHitCount, dbHitCount, len(unsolvedQuestions))
+	logger.Info("本地答案匹配完成", "bank_hits", bankHitCount, "dictionary_hits", dbHitCount, "unsolved", len(unsolvedQuestions))
 
 	aiSolvedCount := 0
-	if len(unsolvedQuestions) > 0 {
-		fmt.Printf("正在将 %d 个问题批量提交给AI...\n", len(unsolvedQuestions))
-		aiAnswers, err := s.aiService.BatchGetAnswersFromAI(unsolvedQuestions)
+	aiSkippedCount := 0
+	var confidences []AIConfidence
+	var aiUsage model.AIUsage
+	userKey := utils.TokenFingerprint(xAuthToken)
+	aiCtx, aiSpan := tracing.Start(ctx, "exam.answer_source.ai", attribute.Int("exam.questions", len(unsolvedQuestions)))
+	// withinBudget 在每次调用AI前检查预算 (计入本次测试已消耗的用量)，超出时剩余的 remaining 道题不再作答
+	withinBudget := func(remaining int) bool {
+		exceeded, reason := s.usageService.BudgetExceeded(userKey, aiUsage.TotalTokens)
+		if exceeded {
+			logger.Warn("AI预算已用尽，跳过剩余AI题目", "reason", reason, "skipped", remaining)
+			aiSkippedCount = remaining
+		}
+		return !exceeded
+	}
+	votingCfg, voting := s.aiService.VotingFor(examType)
+	switch {
+	case len(unsolvedQuestions) == 0 || !withinBudget(len(unsolvedQuestions)):
+		// 没有需要AI作答的题目，或预算在AI阶段开始前已用尽
+	case voting:
+		logger.Info("投票模式已开启，正在逐题处理", "samples", votingCfg.Samples, "questions", len(unsolvedQuestions))
+		for i, q := range unsolvedQuestions {
+			if i > 0 && !withinBudget(len(unsolvedQuestions)-i) {
+				break
+			}
+			voted, usage, voteErr := s.aiService.VoteAnswerFromAI(aiCtx, q, votingCfg)
+			aiUsage.Add(usage)
+			if voteErr != nil {
+				logger.Warn("投票处理问题失败", "title", q.Title, "paper_detail_id", q.PaperDetailID, "error", voteErr)
+				continue
+			}
+			mu.Lock()
+			finalAnswers[q.PaperDetailID] = voted.Answer
+			mu.Unlock()
+			confidences = append(confidences, AIConfidence{
+				PaperDetailID: q.PaperDetailID,
+				Title:         q.Title,
+				Answer:        voted.Answer,
+				Confidence:    voted.Confidence,
+				Tied:          voted.Tied,
+				Votes:         voted.Votes,
+			})
+			aiSolvedCount++
+		}
+		logger.Info("投票处理完成", "solved", aiSolvedCount)
+	default:
+		logger.Info("正在将问题批量提交给AI", "questions", len(unsolvedQuestions))
+		aiAnswers, usage, err := s.aiService.BatchGetAnswersFromAI(aiCtx, unsolvedQuestions)
+		aiUsage.Add(usage)
 		if err != nil {
-			fmt.Printf("AI批量处理失败: %v。正在回退到逐个问题处理模式...\n", err)
-			for _, q := range unsolvedQuestions {
-				fmt.Printf("... 正在单独处理问题: '%s'\n", q.Title)
-				singleAnswer, singleErr := s.aiService.GetAnswerFromAI(q)
+			logger.Warn("AI批量处理失败，回退到逐个问题处理模式", "error", err)
+			for i, q := range unsolvedQuestions {
+				if !withinBudget(len(unsolvedQuestions) - i) {
+					break
+				}
+				singleAnswer, usage, singleErr := s.aiService.GetAnswerFromAI(aiCtx, q)
+				aiUsage.Add(usage)
 				if singleErr != nil {
-					fmt.Printf("警告: 单独处理问题 '%s' (ID: %s) 失败: %v\n", q.Title, q.PaperDetailID, singleErr)
+					logger.Warn("单独处理问题失败", "title", q.Title, "paper_detail_id", q.PaperDetailID, "error", singleErr)
 					continue
 				}
 				mu.Lock()
@@ -159,7 +260,7 @@ func (s *ExamService) ProcessTest(xAuthToken string, delaySeconds int, week int,
 				mu.Unlock()
 				aiSolvedCount++
 			}
-			fmt.Printf("逐个问题处理完成，成功获取 %d 个答案。\n", aiSolvedCount)
+			logger.Info("逐个问题处理完成", "solved", aiSolvedCount)
 		} else {
 			mu.Lock()
 			for i, question := range unsolvedQuestions {
@@ -169,19 +270,38 @@ func (s *ExamService) ProcessTest(xAuthToken string, delaySeconds int, week int,
 			}
 			mu.Unlock()
 			aiSolvedCount = len(aiAnswers)
-			fmt.Printf("AI成功返回 %d 个答案，已合并。\n", len(aiAnswers))
+			logger.Info("AI批量处理完成", "solved", len(aiAnswers))
 		}
 	}
 
+	aiSpan.SetAttributes(attribute.Int("exam.hits", aiSolvedCount), attribute.Int("exam.budget_skipped", aiSkippedCount))
+	aiSpan.End()
+
+	metrics.AnswerSourceHits.WithLabelValues(metrics.SourceBank).Add(float64(bankHitCount))
+	metrics.AnswerSourceHits.WithLabelValues(metrics.SourceDictionary).Add(float64(dbHitCount))
+	metrics.AnswerSourceHits.WithLabelValues(metrics.SourceAI).Add(float64(aiSolvedCount))
+	metrics.AnswerSourceHits.WithLabelValues(metrics.SourceUnanswered).Add(float64(len(unsolvedQuestions) - aiSolvedCount))
+
+	if aiUsage.TotalTokens > 0 {
+		s.usageService.Record(userKey, paper.PaperID, aiUsage)
+		logger.Info("本次测试AI用量", "prompt_tokens", aiUsage.PromptTokens, "completion_tokens", aiUsage.CompletionTokens, "total_tokens", aiUsage.TotalTokens)
+	}
+
 	totalQuestions := len(paper.List)
 
+	var requestedCorrect *int
+	if correctCount >= 0 {
+		requested := correctCount
+		requestedCorrect = &requested
+	}
 	if correctCount < 0 || correctCount > totalQuestions {
 		correctCount = totalQuestions
 	}
 
 	numToMakeIncorrect := totalQuestions - correctCount
+	changedCount := 0
 	if numToMakeIncorrect > 0 {
-		log.Printf("[CorrectnessControl] 目标正确题数: %d/%d。需要故意改错 %d 题。", correctCount, totalQuestions, numToMakeIncorrect)
+		logger.Info("正确率控制: 需要故意改错部分题目", "target_correct", correctCount, "total", totalQuestions, "to_modify", numToMakeIncorrect)
 
 		var candidates []answerToModify
 		for _, q := range paper.List {
@@ -198,7 +318,6 @@ func (s *ExamService) ProcessTest(xAuthToken string, delaySeconds int, week int,
 			return candidates[i].Level > candidates[j].Level
 		})
 
-		changedCount := 0
 		for i := 0; i < numToMakeIncorrect && i < len(candidates); i++ {
 			candidate := candidates[i]
 
@@ -219,9 +338,8 @@ func (s *ExamService) ProcessTest(xAuthToken string, delaySeconds int, week int,
 
 			finalAnswers[candidate.PaperDetailID] = wrongAnswer
 			changedCount++
-			// log.Printf("[CorrectnessControl] 已将题目 (ID: %s, 难度: %d) 的答案从 %s 修改为 %s。", candidate.PaperDetailID, candidate.Level, candidate.CorrectAnswer, wrongAnswer)
 		}
-		log.Printf("[CorrectnessControl] 共成功修改了 %d 道题的答案。", changedCount)
+		logger.Info("正确率控制完成", "modified", changedCount)
 	}
 
 	submissionList := make([]model.AnswerInput, len(paper.List))
@@ -243,41 +361,93 @@ func (s *ExamService) ProcessTest(xAuthToken string, delaySeconds int, week int,
 		elapsed := time.Since(startTime)
 
 		totalDuration := time.Duration(delaySeconds) * time.Second
-		fmt.Printf("所有答案已准备就绪。将等待 %d 秒后提交...\n", delaySeconds)
 		waitTime := totalDuration - elapsed + 300*time.Millisecond
 		if waitTime > 0 {
-			fmt.Printf("答案计算耗时 %.2f 秒。将再等待 %.2f 秒以达到总时长 %d 秒后提交...\n", elapsed.Seconds(), waitTime.Seconds(), delaySeconds)
+			logger.Info("所有答案已准备就绪，等待后提交", "elapsed", elapsed.Round(time.Millisecond), "wait", waitTime.Round(time.Millisecond), "delay_seconds", delaySeconds)
+			_, waitSpan := tracing.Start(ctx, "exam.submission_wait", attribute.Int64("exam.wait_ms", waitTime.Milliseconds()))
 			time.Sleep(waitTime)
+			waitSpan.End()
 		} else {
-			fmt.Printf("答案计算耗时 %.2f 秒，已超过设定的 %d 秒延迟，将立即提交。\n", elapsed.Seconds(), delaySeconds)
+			logger.Info("答案计算耗时已超过设定的延迟，将立即提交", "elapsed", elapsed.Round(time.Millisecond), "delay_seconds", delaySeconds)
 		}
 	}
 
-	fmt.Println("正在提交试卷...")
+	logger.Info("正在提交试卷")
 
-	if err := s.hduClient.SubmitPaper(xAuthToken, &submission); err != nil {
-		return "提交试卷失败", err
+	if err := s.hduClient.SubmitPaper(ctx, xAuthToken, &submission); err != nil {
+		return nil, err
 	}
-	fmt.Println("测试请求处理成功！")
-	go s.learnFromTestResult(xAuthToken, paper.PaperID)
-	return fmt.Sprintf("自动化测试成功完成并提交！答案库命中 %d, 题库命中 %d, AI成功处理 %d。", bankHitCount, dbHitCount, aiSolvedCount), nil
+	submittedAt := time.Now()
+	logger.Info("测试请求处理成功", "duration", submittedAt.Sub(startTime).Round(time.Millisecond))
+	if username != "" {
+		record := repository.TestRecord{
+			PaperID:          paper.PaperID,
+			Week:             week,
+			ExamType:         examType,
+			Questions:        totalQuestions,
+			RequestedCorrect: requestedCorrect,
+			Sources: repository.AnswerSources{
+				Bank:       bankHitCount,
+				Dictionary: dbHitCount,
+				AI:         aiSolvedCount,
+				Unanswered: len(unsolvedQuestions) - aiSolvedCount,
+				Modified:   changedCount,
+			},
+			DelaySeconds: delaySeconds,
+			StartedAt:    startTime,
+			SubmittedAt:  submittedAt,
+			DurationMs:   submittedAt.Sub(startTime).Milliseconds(),
+		}
+		if err := s.historyRepo.Add(username, record); err != nil {
+			logger.Error("保存测试记录失败", "error", err)
+		}
+	}
+	// 学习协程在请求结束后继续运行，不能跟随请求的 context 被取消
+	go s.learnFromTestResult(context.WithoutCancel(ctx), xAuthToken, username, paper.PaperID, week, examType)
+	message := fmt.Sprintf("自动化测试成功完成并提交！答案库命中 %d, 题库命中 %d, AI成功处理 %d。", bankHitCount, dbHitCount, aiSolvedCount)
+	if aiSkippedCount > 0 {
+		message += fmt.Sprintf(" AI预算已用尽，%d 题未作答。", aiSkippedCount)
+	}
+	if len(confidences) > 0 {
+		var sum float64
+		for _, c := range confidences {
+			sum += c.Confidence
+		}
+		message += fmt.Sprintf(" AI平均置信度 %.0f%%。", sum/float64(len(confidences))*100)
+	}
+	return &TestResult{Message: message, AIConfidence: confidences}, nil
 }
 
-func (s *ExamService) learnFromTestResult(xAuthToken, paperID string) {
-	log.Printf("[Learn] 学习协程已启动 (PaperID: %s)", paperID)
+func (s *ExamService) learnFromTestResult(ctx context.Context, xAuthToken, username, paperID string, week, examType int) {
+	// 学习任务在请求返回后才运行，作为新的 trace 记录，并链接回发起它的请求
+	ctx, span := tracing.StartLinkedRoot(ctx, "exam.learn", attribute.String("exam.paper_id", paperID))
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	logger := logging.FromContext(ctx, s.logger).With("component", "learn", "job_id", "learn-"+paperID, "paper_id", paperID)
+	ctx = logging.WithContext(ctx, logger)
+
 	learningDelay := 5 * time.Second
-	log.Printf("[Learn] 将等待 %v 后开始获取答案...", learningDelay)
+	logger.Info("学习协程已启动", "delay", learningDelay)
 	time.Sleep(learningDelay)
 
-	log.Printf("[Learn] 正在为试卷 %s 获取官方答案...", paperID)
-
-	detail, err := s.hduClient.FetchPaperDetail(xAuthToken, paperID)
+	detail, err := s.hduClient.FetchPaperDetail(ctx, xAuthToken, paperID)
 	if err != nil {
-		log.Printf("!!! 致命错误 (考后学习): 获取试卷详情时出错: %v", err)
-		log.Printf("[Learn] 学习协程异常退出 (PaperID: %s)", paperID)
+		metrics.LearningRuns.WithLabelValues("fetch_failed").Inc()
+		logger.Error("学习协程异常退出: 获取试卷详情时出错", "error", err)
 		return
 	}
-	// log.Printf("[Learn] 成功获取试卷详情，共 %d 道题。", len(detail.List))
+
+	// 保存试卷详情供错题本使用，并补充测试记录的得分，失败不影响学习答案
+	if username != "" {
+		record := repository.PaperRecord{PaperID: paperID, Week: week, ExamType: examType, Mark: detail.Mark, FetchedAt: time.Now(), List: detail.List}
+		if saveErr := s.paperRepo.Save(username, record); saveErr != nil {
+			logger.Error("保存试卷详情失败", "error", saveErr)
+		}
+		if saveErr := s.historyRepo.SetMark(username, paperID, detail.Mark); saveErr != nil {
+			logger.Error("更新测试记录得分失败", "error", saveErr)
+		}
+	}
 
 	newAnswersToSave := make(map[string]string)
 	for _, item := range detail.List {
@@ -291,12 +461,12 @@ func (s *ExamService) learnFromTestResult(xAuthToken, paperID string) {
 		fingerprint := generateQuestionFingerprint(q)
 		newAnswersToSave[fingerprint] = item.Answer
 	}
-	// log.Printf("[Learn] 已从详情中提取 %d 条答案准备存入银行。", len(newAnswersToSave))
 
-	if err := s.answerBankRepo.Save(newAnswersToSave); err != nil {
-		log.Printf("!!! 致命错误 (考后学习): 保存到答案银行时出错: %v", err)
-		log.Printf("[Learn] 学习协程异常退出 (PaperID: %s)", paperID)
+	if err = s.answerBankRepo.Save(newAnswersToSave, paperID); err != nil {
+		metrics.LearningRuns.WithLabelValues("save_failed").Inc()
+		logger.Error("学习协程异常退出: 保存到答案银行时出错", "error", err)
 		return
 	}
-	log.Printf("[Learn] 学习协程成功完成 (PaperID: %s)", paperID)
+	metrics.LearningRuns.WithLabelValues("success").Inc()
+	logger.Info("学习协程成功完成", "answers", len(newAnswersToSave))
 }
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/version"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+)
+
+// HealthConfig 控制就绪检查。CheckAIReachability 为 true 时会实际请求 AI 服务的 /models 接口。
+type HealthConfig struct {
+	CheckAIReachability bool `mapstructure:"check_ai_reachability"`
+	TimeoutSeconds      int  `mapstructure:"timeout_seconds"`
+}
+
+// CheckResult 是单项检查的结果。
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	Details    any    `json:"details,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// HealthReport 是 /healthz 和 /readyz 的响应体。
+type HealthReport struct {
+	Status  string                 `json:"status"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+	Version version.Info           `json:"version"`
+	Uptime  string                 `json:"uptime"`
+}
+
+type HealthService struct {
+	wordRepo       *repository.WordRepository
+	answerBankRepo *repository.AnswerBankRepository
+	aiService      *AIService
+	cfg            HealthConfig
+	startedAt      time.Time
+}
+
+func NewHealthService(wordRepo *repository.WordRepository, answerBankRepo *repository.AnswerBankRepository, aiService *AIService, cfg HealthConfig) *HealthService {
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = 3
+	}
+	return &HealthService{
+		wordRepo:       wordRepo,
+		answerBankRepo: answerBankRepo,
+		aiService:      aiService,
+		cfg:            cfg,
+		startedAt:      time.Now(),
+	}
+}
+
+// Liveness 只说明进程仍在响应，不检查任何依赖。
+func (s *HealthService) Liveness() *HealthReport {
+	return &HealthReport{Status: StatusUp, Version: version.Get(), Uptime: time.Since(s.startedAt).Round(time.Second).String()}
+}
+
+type healthCheck struct {
+	name string
+	run  func(ctx context.Context) (any, error)
+}
+
+// Readiness 并发运行所有依赖检查，任意一项失败时整体状态为 DOWN。
+func (s *HealthService) Readiness(ctx context.Context) *HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	checks := []healthCheck{
+		{name: "word_repository", run: s.checkWordRepo},
+		{name: "answer_bank", run: s.checkAnswerBank},
+		{name: "ai_config", run: s.checkAIConfig},
+	}
+	if s.cfg.CheckAIReachability {
+		checks = append(checks, healthCheck{name: "ai_reachable", run: s.checkAIReachable})
+	}
+
+	report := s.Liveness()
+	report.Checks = make(map[string]CheckResult, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check healthCheck) {
+			defer wg.Done()
+			start := time.Now()
+			details, err := check.run(ctx)
+			result := CheckResult{Status: StatusUp, Details: details, DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.name] = result
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
+
+func (s *HealthService) checkWordRepo(context.Context) (any, error) {
+	words, meanings := s.wordRepo.Size()
+	details := map[string]int{"words": words, "meanings": meanings}
+	if words == 0 || meanings == 0 {
+		return details, fmt.Errorf("题库为空")
+	}
+	return details, nil
+}
+
+func (s *HealthService) checkAnswerBank(context.Context) (any, error) {
+	return map[string]int{"entries": s.answerBankRepo.Size()}, s.answerBankRepo.CheckWritable()
+}
+
+func (s *HealthService) checkAIConfig(context.Context) (any, error) {
+	return map[string]string{"model": s.aiService.ModelName()}, s.aiService.CheckConfig()
+}
+
+func (s *HealthService) checkAIReachable(ctx context.Context) (any, error) {
+	return nil, s.aiService.Ping(ctx)
+}
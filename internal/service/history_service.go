@@ -0,0 +1,95 @@
+package service
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"sort"
+)
+
+// MarkStats 是一组测试的得分统计。Graded 为已获取到得分的测试数，平均分和最高分只统计这部分。
+type MarkStats struct {
+	Tests       int     `json:"tests"`
+	Graded      int     `json:"graded"`
+	AverageMark float64 `json:"average_mark"`
+	BestMark    int     `json:"best_mark"`
+	LatestMark  *int    `json:"latest_mark,omitempty"`
+}
+
+// WeekStats 是某一周的得分统计。
+type WeekStats struct {
+	Week int `json:"week"`
+	MarkStats
+}
+
+// HistoryStats 是用户所有测试的统计，Weeks 按周数升序。
+type HistoryStats struct {
+	MarkStats
+	Sources repository.AnswerSources `json:"sources"`
+	Weeks   []WeekStats              `json:"weeks"`
+}
+
+type HistoryService struct {
+	repo *repository.HistoryRepository
+}
+
+func NewHistoryService(repo *repository.HistoryRepository) *HistoryService {
+	return &HistoryService{repo: repo}
+}
+
+// List 返回用户的测试记录，最近的在前。week 为 0 时不按周筛选。返回分页后的记录和记录总数。
+func (s *HistoryService) List(username string, week, offset, limit int) ([]repository.TestRecord, int) {
+	filtered := make([]repository.TestRecord, 0)
+	for _, record := range s.repo.List(username) {
+		if week == 0 || record.Week == week {
+			filtered = append(filtered, record)
+		}
+	}
+	total := len(filtered)
+	if offset >= total {
+		return []repository.TestRecord{}, total
+	}
+	end := min(offset+limit, total)
+	return filtered[offset:end], total
+}
+
+// add 统计一次测试。记录需按时间倒序传入，LatestMark 取第一个有得分的记录。
+func (m *MarkStats) add(record repository.TestRecord) {
+	m.Tests++
+	if record.Mark == nil {
+		return
+	}
+	mark := *record.Mark
+	if m.LatestMark == nil {
+		m.LatestMark = &mark
+	}
+	if m.Graded == 0 || mark > m.BestMark {
+		m.BestMark = mark
+	}
+	m.AverageMark = (m.AverageMark*float64(m.Graded) + float64(mark)) / float64(m.Graded+1)
+	m.Graded++
+}
+
+// Stats 汇总用户的所有测试记录，按周给出得分变化。
+func (s *HistoryService) Stats(username string) HistoryStats {
+	stats := HistoryStats{Weeks: make([]WeekStats, 0)}
+	byWeek := make(map[int]*WeekStats)
+	for _, record := range s.repo.List(username) {
+		stats.add(record)
+		stats.Sources.Bank += record.Sources.Bank
+		stats.Sources.Dictionary += record.Sources.Dictionary
+		stats.Sources.AI += record.Sources.AI
+		stats.Sources.Unanswered += record.Sources.Unanswered
+		stats.Sources.Modified += record.Sources.Modified
+
+		w, ok := byWeek[record.Week]
+		if !ok {
+			w = &WeekStats{Week: record.Week}
+			byWeek[record.Week] = w
+		}
+		w.add(record)
+	}
+	for _, w := range byWeek {
+		stats.Weeks = append(stats.Weeks, *w)
+	}
+	sort.Slice(stats.Weeks, func(i, j int) bool { return stats.Weeks[i].Week < stats.Weeks[j].Week })
+	return stats
+}
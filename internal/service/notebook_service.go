@@ -0,0 +1,62 @@
+package service
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"time"
+)
+
+// AnnotatedQuestion 是学校平台返回的题目详情，附带正确选项的内容和题库中的释义。
+type AnnotatedQuestion struct {
+	model.QuestionDetail
+	RightAnswer string `json:"right_answer"`
+	Word        string `json:"word,omitempty"`
+	Definition  string `json:"definition,omitempty"`
+}
+
+func annotate(wordRepo *repository.WordRepository, item model.QuestionDetail) AnnotatedQuestion {
+	q := AnnotatedQuestion{QuestionDetail: item, RightAnswer: optionText(item, item.Answer)}
+	q.Word, q.Definition, _ = questionWord(wordRepo, item)
+	return q
+}
+
+// Mistake 是错题本中的一道题。
+type Mistake struct {
+	PaperID string    `json:"paper_id"`
+	Week    int       `json:"week"`
+	TakenAt time.Time `json:"taken_at"`
+	AnnotatedQuestion
+}
+
+// NotebookService 基于保存的试卷详情生成错题本。
+type NotebookService struct {
+	wordRepo  *repository.WordRepository
+	paperRepo *repository.PaperRepository
+}
+
+func NewNotebookService(wordRepo *repository.WordRepository, paperRepo *repository.PaperRepository) *NotebookService {
+	return &NotebookService{wordRepo: wordRepo, paperRepo: paperRepo}
+}
+
+// Mistakes 返回用户答错的题目，最近的试卷在前。week 为 0 时不按周筛选。
+// 返回分页后的错题和错题总数。
+func (s *NotebookService) Mistakes(username string, week, offset, limit int) ([]Mistake, int) {
+	mistakes := make([]Mistake, 0)
+	total := 0
+	for _, paper := range s.paperRepo.List(username) {
+		if week > 0 && paper.Week != week {
+			continue
+		}
+		for _, item := range paper.List {
+			if item.Right {
+				continue
+			}
+			total++
+			if total <= offset || len(mistakes) >= limit {
+				continue
+			}
+			mistakes = append(mistakes, Mistake{PaperID: paper.PaperID, Week: paper.Week, TakenAt: paper.FetchedAt, AnnotatedQuestion: annotate(s.wordRepo, item)})
+		}
+	}
+	return mistakes, total
+}
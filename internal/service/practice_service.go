@@ -0,0 +1,155 @@
+package service
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PracticeItemResult 是一道练习题的批改结果。未作答的题目 Input 为 nil，计为答错。
+type PracticeItemResult struct {
+	PaperDetailID string  `json:"paperDetailId"`
+	Word          string  `json:"word"`
+	Input         *string `json:"input"`
+	Answer        string  `json:"answer"`
+	Right         bool    `json:"right"`
+}
+
+// PracticeResult 是一次练习的成绩，Mark 为百分制得分。
+type PracticeResult struct {
+	PaperID string               `json:"paperId"`
+	Total   int                  `json:"total"`
+	Correct int                  `json:"correct"`
+	Mark    int                  `json:"mark"`
+	List    []PracticeItemResult `json:"list"`
+}
+
+// WordMastery 是单词的掌握情况，Accuracy 为 0-1 的正确率。
+type WordMastery struct {
+	repository.WordStat
+	Definition string  `json:"definition,omitempty"`
+	Accuracy   float64 `json:"accuracy"`
+}
+
+// MasteryReport 是用户的练习概况及最薄弱的单词。
+type MasteryReport struct {
+	PracticedWords int           `json:"practiced_words"`
+	Attempts       int           `json:"attempts"`
+	Accuracy       float64       `json:"accuracy"`
+	Weakest        []WordMastery `json:"weakest"`
+}
+
+type PracticeService struct {
+	quizService *QuizService
+	wordRepo    *repository.WordRepository
+	repo        *repository.PracticeRepository
+	logger      *slog.Logger
+}
+
+func NewPracticeService(quizService *QuizService, wordRepo *repository.WordRepository, repo *repository.PracticeRepository, logger *slog.Logger) *PracticeService {
+	return &PracticeService{quizService: quizService, wordRepo: wordRepo, repo: repo, logger: logger}
+}
+
+// Start 为用户随机生成一套练习试卷并保存答案，返回不含答案的试卷。
+func (s *PracticeService) Start(ctx context.Context, username string, count int, mode string) (*model.PaperResponse, error) {
+	generated, err := s.quizService.Generate(count, mode, nil)
+	if err != nil {
+		return nil, err
+	}
+	paper := repository.PracticePaper{Username: username, CreatedAt: time.Now(), Key: generated.Key}
+	if err := s.repo.SavePaper(generated.Paper.PaperID, paper); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx, s.logger).Info("练习试卷已生成", "component", "practice", "paper_id", generated.Paper.PaperID, "questions", len(generated.Paper.List), "mode", mode)
+	return &generated.Paper, nil
+}
+
+// Submit 批改用户提交的练习试卷并记录每个单词的对错。每套试卷只能提交一次。
+func (s *PracticeService) Submit(ctx context.Context, username string, payload model.SubmissionPayload) (*PracticeResult, error) {
+	inputs := make(map[string]*string, len(payload.List))
+	for _, item := range payload.List {
+		inputs[item.PaperDetailID] = item.Input
+	}
+
+	result := &PracticeResult{PaperID: payload.PaperID}
+	err := s.repo.CompletePaper(username, payload.PaperID, time.Now(), func(paper repository.PracticePaper) []repository.WordResult {
+		ids := make([]string, 0, len(paper.Key))
+		for id := range paper.Key {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		words := make([]repository.WordResult, 0, len(ids))
+		for _, id := range ids {
+			key := paper.Key[id]
+			input := inputs[id]
+			right := input != nil && strings.EqualFold(strings.TrimSpace(*input), key.Answer)
+			result.List = append(result.List, PracticeItemResult{PaperDetailID: id, Word: key.Word, Input: input, Answer: key.Answer, Right: right})
+			words = append(words, repository.WordResult{Word: key.Word, Right: right})
+			if right {
+				result.Correct++
+			}
+		}
+		return words
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Total = len(result.List)
+	if result.Total > 0 {
+		result.Mark = (result.Correct*100 + result.Total/2) / result.Total
+	}
+	logging.FromContext(ctx, s.logger).Info("练习试卷已批改", "component", "practice", "paper_id", payload.PaperID, "total", result.Total, "correct", result.Correct)
+	return result, nil
+}
+
+// weakness 是加一平滑后的错误率，作答次数少的单词不会因一次答错就排在最前。
+func weakness(s repository.WordStat) float64 {
+	return float64(s.Incorrect+1) / float64(s.Correct+s.Incorrect+2)
+}
+
+// Mastery 返回用户的练习概况和最薄弱的 limit 个单词。
+// 排序依据为平滑后的错误率，其次是答错次数和最近一次作答时间。
+func (s *PracticeService) Mastery(username string, limit int) MasteryReport {
+	stats := s.repo.WordStats(username)
+	report := MasteryReport{PracticedWords: len(stats), Weakest: make([]WordMastery, 0)}
+	correct := 0
+	for _, stat := range stats {
+		report.Attempts += stat.Correct + stat.Incorrect
+		correct += stat.Correct
+	}
+	if report.Attempts > 0 {
+		report.Accuracy = float64(correct) / float64(report.Attempts)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		wi, wj := weakness(stats[i]), weakness(stats[j])
+		if wi != wj {
+			return wi > wj
+		}
+		if stats[i].Incorrect != stats[j].Incorrect {
+			return stats[i].Incorrect > stats[j].Incorrect
+		}
+		if !stats[i].LastSeen.Equal(stats[j].LastSeen) {
+			return stats[i].LastSeen.After(stats[j].LastSeen)
+		}
+		return stats[i].Word < stats[j].Word
+	})
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+	for _, stat := range stats {
+		m := WordMastery{WordStat: stat, Definition: s.wordRepo.FindDefinitionByWord(stat.Word)}
+		if total := stat.Correct + stat.Incorrect; total > 0 {
+			m.Accuracy = float64(stat.Correct) / float64(total)
+		}
+		report.Weakest = append(report.Weakest, m)
+	}
+	return report
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/quiz"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"math/rand/v2"
+	"sync"
+)
+
+// QuizService 用本地题库生成练习试卷。出题器在题库重新加载后自动重建。
+type QuizService struct {
+	wordRepo *repository.WordRepository
+
+	mu        sync.Mutex
+	generator *quiz.Generator
+	version   uint64
+}
+
+func NewQuizService(wordRepo *repository.WordRepository) *QuizService {
+	return &QuizService{wordRepo: wordRepo}
+}
+
+func (s *QuizService) currentGenerator() *quiz.Generator {
+	definitions, version := s.wordRepo.Definitions()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generator == nil || s.version != version {
+		s.generator = quiz.NewGenerator(definitions)
+		s.version = version
+	}
+	return s.generator
+}
+
+// Generate 生成 count 道题。seed 不为 nil 时结果可复现。
+func (s *QuizService) Generate(count int, mode string, seed *uint64) (*quiz.Quiz, error) {
+	opts := quiz.Options{Count: count, Mode: mode}
+	if seed != nil {
+		opts.Rand = rand.New(rand.NewPCG(*seed, *seed))
+	}
+	return s.currentGenerator().Generate(opts)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/client"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"context"
+	"errors"
+)
+
+// ErrEmptyPaper 表示学校平台没有返回该试卷的题目，通常是试卷不存在或尚未提交。
+var ErrEmptyPaper = errors.New("试卷不存在或尚未提交")
+
+// ReviewItem 是回顾试卷中的一道题。InBank 表示答案银行中已有该题，BankAnswer 为银行中的答案。
+type ReviewItem struct {
+	AnnotatedQuestion
+	InBank     bool   `json:"in_bank"`
+	BankAnswer string `json:"bank_answer,omitempty"`
+}
+
+// PaperReview 是一套试卷的回顾：学校平台的批改详情及统计。
+type PaperReview struct {
+	PaperID string       `json:"paper_id"`
+	Mark    int          `json:"mark"`
+	Total   int          `json:"total"`
+	Correct int          `json:"correct"`
+	InBank  int          `json:"in_bank"`
+	List    []ReviewItem `json:"list"`
+}
+
+// ReviewService 从学校平台获取用户做过的试卷，并用题库和答案银行标注每道题。
+type ReviewService struct {
+	hduClient      *client.HduApiClient
+	wordRepo       *repository.WordRepository
+	answerBankRepo *repository.AnswerBankRepository
+}
+
+func NewReviewService(hduClient *client.HduApiClient, wordRepo *repository.WordRepository, answerBankRepo *repository.AnswerBankRepository) *ReviewService {
+	return &ReviewService{hduClient: hduClient, wordRepo: wordRepo, answerBankRepo: answerBankRepo}
+}
+
+func (s *ReviewService) ReviewPaper(ctx context.Context, xAuthToken, paperID string) (*PaperReview, error) {
+	detail, err := s.hduClient.FetchPaperDetail(ctx, xAuthToken, paperID)
+	if err != nil {
+		return nil, err
+	}
+	if len(detail.List) == 0 {
+		return nil, ErrEmptyPaper
+	}
+
+	review := &PaperReview{PaperID: detail.PaperID, Mark: detail.Mark, Total: len(detail.List), List: make([]ReviewItem, 0, len(detail.List))}
+	if review.PaperID == "" {
+		review.PaperID = paperID
+	}
+	for _, item := range detail.List {
+		q := model.Question{Title: item.Title, AnswerA: item.AnswerA, AnswerB: item.AnswerB, AnswerC: item.AnswerC, AnswerD: item.AnswerD}
+		r := ReviewItem{AnnotatedQuestion: annotate(s.wordRepo, item)}
+		r.BankAnswer, r.InBank = s.answerBankRepo.Query(generateQuestionFingerprint(q))
+		if item.Right {
+			review.Correct++
+		}
+		if r.InBank {
+			review.InBank++
+		}
+		review.List = append(review.List, r)
+	}
+	return review, nil
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/client"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/logging"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SM-2 的初始易度和最低易度
+const (
+	initialEasiness = 2.5
+	minEasiness     = 1.3
+)
+
+var (
+	// ErrEmptyDeck 表示所选试卷中没有能在题库里找到的单词。
+	ErrEmptyDeck = errors.New("试卷中没有可以加入卡组的单词")
+	// ErrNoWeekPapers 表示没有用户该周的试卷记录。
+	ErrNoWeekPapers = errors.New("没有该周的试卷记录")
+)
+
+// DeckSource 描述卡组的来源：用户做过的一组试卷，OnlyWrong 为 true 时只加入答错的题目。
+// PaperIDs 为空时使用试卷记录中第 Week 周的全部试卷。
+type DeckSource struct {
+	Name      string
+	Week      int
+	PaperIDs  []string
+	OnlyWrong bool
+}
+
+// DeckSummary 是卡组的概况，不包含卡片。
+type DeckSummary struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Week      int       `json:"week,omitempty"`
+	PaperIDs  []string  `json:"paper_ids,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Cards     int       `json:"cards"`
+	Due       int       `json:"due"`
+}
+
+type StudyService struct {
+	hduClient      *client.HduApiClient
+	wordRepo       *repository.WordRepository
+	answerBankRepo *repository.AnswerBankRepository
+	paperRepo      *repository.PaperRepository
+	repo           *repository.StudyRepository
+	logger         *slog.Logger
+}
+
+func NewStudyService(hduClient *client.HduApiClient, wordRepo *repository.WordRepository, answerBankRepo *repository.AnswerBankRepository, paperRepo *repository.PaperRepository, repo *repository.StudyRepository, logger *slog.Logger) *StudyService {
+	return &StudyService{hduClient: hduClient, wordRepo: wordRepo, answerBankRepo: answerBankRepo, paperRepo: paperRepo, repo: repo, logger: logger}
+}
+
+func summarize(deck repository.StudyDeck, now time.Time) DeckSummary {
+	summary := DeckSummary{ID: deck.ID, Name: deck.Name, Week: deck.Week, PaperIDs: deck.PaperIDs, CreatedAt: deck.CreatedAt, Cards: len(deck.Cards)}
+	for _, card := range deck.Cards {
+		if !card.Due.After(now) {
+			summary.Due++
+		}
+	}
+	return summary
+}
+
+// optionText 返回选项字母对应的选项内容。
+func optionText(item model.QuestionDetail, letter string) string {
+	switch strings.ToUpper(strings.TrimSpace(letter)) {
+	case "A":
+		return item.AnswerA
+	case "B":
+		return item.AnswerB
+	case "C":
+		return item.AnswerC
+	case "D":
+		return item.AnswerD
+	}
+	return ""
+}
+
+// questionWord 返回题目考查的英文单词及释义：英译中取题干，中译英取正确选项。
+func questionWord(wordRepo *repository.WordRepository, item model.QuestionDetail) (string, string, bool) {
+	candidate := strings.TrimSpace(strings.TrimRight(item.Title, ". "))
+	if !utils.IsEnglish(candidate) {
+		candidate = strings.TrimSpace(strings.TrimRight(optionText(item, item.Answer), ". "))
+	}
+	if candidate == "" {
+		return "", "", false
+	}
+	return wordRepo.LookupDefinition(candidate)
+}
+
+// questionCard 返回题目对应卡片的单词和释义。答案银行中有该题确认过的答案时，以该答案对应的
+// 义项作为释义 (即测试实际考查的义项)，题库中没有的单词也能加入卡组；否则使用题库释义。
+func (s *StudyService) questionCard(item model.QuestionDetail) (string, string, bool) {
+	q := model.Question{Title: item.Title, AnswerA: item.AnswerA, AnswerB: item.AnswerB, AnswerC: item.AnswerC, AnswerD: item.AnswerD}
+	bankAnswer, confirmed := s.answerBankRepo.Query(generateQuestionFingerprint(q))
+	if !confirmed {
+		return questionWord(s.wordRepo, item)
+	}
+
+	// 英译中：题干是单词，答案是中文义项；中译英反之
+	word := strings.TrimSpace(strings.TrimRight(item.Title, ". "))
+	meaning := strings.TrimSpace(strings.TrimRight(optionText(item, bankAnswer), ". "))
+	if !utils.IsEnglish(word) {
+		word, meaning = meaning, word
+	}
+	if word == "" || meaning == "" {
+		return questionWord(s.wordRepo, item)
+	}
+	if dictWord, _, ok := s.wordRepo.LookupDefinition(word); ok {
+		word = dictWord
+	}
+	return word, meaning, true
+}
+
+// deckPapers 返回卡组来源的试卷题目。指定了试卷 ID 时优先使用试卷记录，没有记录的再向学校平台获取；
+// 只指定周数时使用试卷记录中该周的全部试卷 (通过本服务完成的测试会保存试卷记录)。
+func (s *StudyService) deckPapers(ctx context.Context, xAuthToken, username string, src DeckSource) ([]string, [][]model.QuestionDetail, error) {
+	if len(src.PaperIDs) == 0 {
+		var paperIDs []string
+		var papers [][]model.QuestionDetail
+		for _, record := range s.paperRepo.List(username) {
+			if record.Week == src.Week {
+				paperIDs = append(paperIDs, record.PaperID)
+				papers = append(papers, record.List)
+			}
+		}
+		if len(papers) == 0 {
+			return nil, nil, ErrNoWeekPapers
+		}
+		return paperIDs, papers, nil
+	}
+
+	papers := make([][]model.QuestionDetail, 0, len(src.PaperIDs))
+	for _, paperID := range src.PaperIDs {
+		if record, err := s.paperRepo.Get(username, paperID); err == nil {
+			papers = append(papers, record.List)
+			continue
+		}
+		detail, err := s.hduClient.FetchPaperDetail(ctx, xAuthToken, paperID)
+		if err != nil {
+			return nil, nil, err
+		}
+		papers = append(papers, detail.List)
+	}
+	return src.PaperIDs, papers, nil
+}
+
+// CreateDeck 把用户做过的试卷中的单词 (去重后) 做成新卡组，新卡片立即到期。
+// 返回卡组概况和既不在答案银行也不在题库中而被跳过的题目数。
+func (s *StudyService) CreateDeck(ctx context.Context, xAuthToken, username string, src DeckSource) (*DeckSummary, int, error) {
+	logger := logging.FromContext(ctx, s.logger).With("component", "study")
+	now := time.Now()
+	paperIDs, papers, err := s.deckPapers(ctx, xAuthToken, username, src)
+	if err != nil {
+		return nil, 0, err
+	}
+	deck := repository.StudyDeck{Name: src.Name, Week: src.Week, PaperIDs: paperIDs}
+	if deck.Name == "" {
+		deck.Name = fmt.Sprintf("%d 套试卷", len(paperIDs))
+		if src.Week > 0 {
+			deck.Name = fmt.Sprintf("第 %d 周", src.Week)
+		}
+	}
+
+	seen := make(map[string]bool)
+	skipped := 0
+	for _, list := range papers {
+		for _, item := range list {
+			if src.OnlyWrong && item.Right {
+				continue
+			}
+			word, definition, ok := s.questionCard(item)
+			if !ok {
+				skipped++
+				continue
+			}
+			if seen[word] {
+				continue
+			}
+			seen[word] = true
+			deck.Cards = append(deck.Cards, repository.StudyCard{Word: word, Definition: definition, Easiness: initialEasiness, Due: now})
+		}
+	}
+	if len(deck.Cards) == 0 {
+		return nil, skipped, ErrEmptyDeck
+	}
+
+	created, err := s.repo.CreateDeck(username, deck)
+	if err != nil {
+		return nil, 0, err
+	}
+	logger.Info("学习卡组已创建", "deck_id", created.ID, "cards", len(created.Cards), "skipped", skipped, "only_wrong", src.OnlyWrong)
+	summary := summarize(created, now)
+	return &summary, skipped, nil
+}
+
+func (s *StudyService) ListDecks(username string, now time.Time) []DeckSummary {
+	decks := s.repo.ListDecks(username)
+	summaries := make([]DeckSummary, 0, len(decks))
+	for _, deck := range decks {
+		summaries = append(summaries, summarize(deck, now))
+	}
+	return summaries
+}
+
+func (s *StudyService) GetDeck(username, deckID string) (repository.StudyDeck, error) {
+	return s.repo.GetDeck(username, deckID)
+}
+
+func (s *StudyService) DeleteDeck(username, deckID string) error {
+	return s.repo.DeleteDeck(username, deckID)
+}
+
+// DueCards 返回已到期的卡片，最早到期的在前。limit <= 0 表示不限制。
+func (s *StudyService) DueCards(username, deckID string, now time.Time, limit int) ([]repository.StudyCard, error) {
+	deck, err := s.repo.GetDeck(username, deckID)
+	if err != nil {
+		return nil, err
+	}
+	due := make([]repository.StudyCard, 0)
+	for _, card := range deck.Cards {
+		if !card.Due.After(now) {
+			due = append(due, card)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].Due.Before(due[j].Due) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Review 记录一次复习。grade 为 0-5 的回忆质量，3 及以上视为记住。
+func (s *StudyService) Review(username, deckID, word string, grade int, now time.Time) (repository.StudyCard, error) {
+	return s.repo.UpdateCard(username, deckID, word, func(card *repository.StudyCard) {
+		scheduleSM2(card, grade, now)
+	})
+}
+
+// scheduleSM2 按 SM-2 算法更新卡片的易度、间隔和下次复习时间。
+func scheduleSM2(card *repository.StudyCard, grade int, now time.Time) {
+	if card.Easiness == 0 {
+		card.Easiness = initialEasiness
+	}
+	if grade >= 3 {
+		switch card.Repetitions {
+		case 0:
+			card.IntervalDays = 1
+		case 1:
+			card.IntervalDays = 6
+		default:
+			card.IntervalDays = int(math.Round(float64(card.IntervalDays) * card.Easiness))
+		}
+		card.Repetitions++
+	} else {
+		card.Repetitions = 0
+		card.IntervalDays = 1
+		card.Lapses++
+	}
+
+	q := float64(5 - grade)
+	card.Easiness = math.Max(minEasiness, card.Easiness+0.1-q*(0.08+q*0.02))
+	card.Due = now.AddDate(0, 0, card.IntervalDays)
+	card.LastReview = &now
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduleSM2Intervals(t *testing.T) {
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	// grade 4 不改变易度，间隔依次为 1、6、round(6*2.5)、round(15*2.5)
+	card := repository.StudyCard{Word: "abandon", Easiness: initialEasiness, Due: now}
+	for i, want := range []int{1, 6, 15, 38} {
+		scheduleSM2(&card, 4, now)
+		if card.IntervalDays != want {
+			t.Fatalf("第 %d 次复习: interval = %d, want %d", i+1, card.IntervalDays, want)
+		}
+		if card.Repetitions != i+1 {
+			t.Fatalf("第 %d 次复习: repetitions = %d, want %d", i+1, card.Repetitions, i+1)
+		}
+		if math.Abs(card.Easiness-initialEasiness) > 1e-9 {
+			t.Fatalf("第 %d 次复习: easiness = %v, want %v", i+1, card.Easiness, initialEasiness)
+		}
+		if wantDue := now.AddDate(0, 0, want); !card.Due.Equal(wantDue) {
+			t.Fatalf("第 %d 次复习: due = %v, want %v", i+1, card.Due, wantDue)
+		}
+		if card.LastReview == nil || !card.LastReview.Equal(now) {
+			t.Fatalf("第 %d 次复习: last_review = %v, want %v", i+1, card.LastReview, now)
+		}
+	}
+}
+
+func TestScheduleSM2(t *testing.T) {
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		card  repository.StudyCard
+		grade int
+		want  repository.StudyCard
+	}{
+		{
+			name:  "答对后易度上升",
+			card:  repository.StudyCard{Easiness: 2.5, IntervalDays: 6, Repetitions: 2},
+			grade: 5,
+			want:  repository.StudyCard{Easiness: 2.6, IntervalDays: 15, Repetitions: 3},
+		},
+		{
+			name:  "答错后重置间隔并记录遗忘",
+			card:  repository.StudyCard{Easiness: 2.5, IntervalDays: 15, Repetitions: 3, Lapses: 1},
+			grade: 2,
+			want:  repository.StudyCard{Easiness: 2.18, IntervalDays: 1, Repetitions: 0, Lapses: 2},
+		},
+		{
+			name:  "易度不低于 1.3",
+			card:  repository.StudyCard{Easiness: 1.3, IntervalDays: 6, Repetitions: 2},
+			grade: 0,
+			want:  repository.StudyCard{Easiness: minEasiness, IntervalDays: 1, Repetitions: 0, Lapses: 1},
+		},
+		{
+			name:  "未设置易度时使用初始值",
+			card:  repository.StudyCard{},
+			grade: 3,
+			want:  repository.StudyCard{Easiness: 2.36, IntervalDays: 1, Repetitions: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := tt.card
+			scheduleSM2(&card, tt.grade, now)
+			if math.Abs(card.Easiness-tt.want.Easiness) > 1e-9 {
+				t.Errorf("easiness = %v, want %v", card.Easiness, tt.want.Easiness)
+			}
+			if card.IntervalDays != tt.want.IntervalDays {
+				t.Errorf("interval = %d, want %d", card.IntervalDays, tt.want.IntervalDays)
+			}
+			if card.Repetitions != tt.want.Repetitions {
+				t.Errorf("repetitions = %d, want %d", card.Repetitions, tt.want.Repetitions)
+			}
+			if card.Lapses != tt.want.Lapses {
+				t.Errorf("lapses = %d, want %d", card.Lapses, tt.want.Lapses)
+			}
+			if wantDue := now.AddDate(0, 0, tt.want.IntervalDays); !card.Due.Equal(wantDue) {
+				t.Errorf("due = %v, want %v", card.Due, wantDue)
+			}
+		})
+	}
+}
+
+func TestQuestionCard(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dbPath := filepath.Join(dir, "database.json")
+	db := `{"wordToDefinition":{"abandon":"vt.放弃 抛弃","ability":"n.能力"},"meaningToWord":{"放弃":"abandon","能力":"ability"}}`
+	if err := os.WriteFile(dbPath, []byte(db), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wordRepo, err := repository.NewWordRepository(dbPath, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bank, err := repository.NewAnswerBankRepository(filepath.Join(dir, "bank.json"), filepath.Join(dir, "stats.json"), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bank.Save(map[string]string{
+		"abandon|放弃|能力|关于|上面":           "A",
+		"能力|ability|abandon|able|above": "A",
+		"zzz|甲|乙|丙|丁":                   "C",
+	}, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	s := &StudyService{wordRepo: wordRepo, answerBankRepo: bank}
+
+	tests := []struct {
+		name           string
+		item           model.QuestionDetail
+		wantWord       string
+		wantDefinition string
+		wantOK         bool
+	}{
+		{
+			name:           "英译中使用银行答案的义项",
+			item:           model.QuestionDetail{Title: "abandon", AnswerA: "放弃", AnswerB: "能力", AnswerC: "关于", AnswerD: "上面", Answer: "A"},
+			wantWord:       "abandon",
+			wantDefinition: "放弃",
+			wantOK:         true,
+		},
+		{
+			name:           "中译英使用银行答案的单词",
+			item:           model.QuestionDetail{Title: "能力", AnswerA: "ability", AnswerB: "abandon", AnswerC: "able", AnswerD: "above"},
+			wantWord:       "ability",
+			wantDefinition: "能力",
+			wantOK:         true,
+		},
+		{
+			name:           "题库中没有的单词使用银行答案",
+			item:           model.QuestionDetail{Title: "zzz", AnswerA: "甲", AnswerB: "乙", AnswerC: "丙", AnswerD: "丁"},
+			wantWord:       "zzz",
+			wantDefinition: "丙",
+			wantOK:         true,
+		},
+		{
+			name:           "银行中没有时使用题库释义",
+			item:           model.QuestionDetail{Title: "ability", AnswerA: "能力", AnswerB: "放弃", AnswerC: "甲", AnswerD: "乙", Answer: "A"},
+			wantWord:       "ability",
+			wantDefinition: "n.能力",
+			wantOK:         true,
+		},
+		{
+			name:   "都没有时跳过",
+			item:   model.QuestionDetail{Title: "unknown", AnswerA: "甲", AnswerB: "乙", AnswerC: "丙", AnswerD: "丁", Answer: "A"},
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			word, definition, ok := s.questionCard(tt.item)
+			if ok != tt.wantOK || word != tt.wantWord || definition != tt.wantDefinition {
+				t.Errorf("questionCard = (%q, %q, %v), want (%q, %q, %v)", word, definition, ok, tt.wantWord, tt.wantDefinition, tt.wantOK)
+			}
+		})
+	}
+}
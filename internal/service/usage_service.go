@@ -0,0 +1,96 @@
+package service
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/model"
+	"HDU-Auto-Word-Ans-Online-Backend/internal/repository"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// BudgetConfig 是 AI 用量预算 (单位: token)，0 表示不限制。
+type BudgetConfig struct {
+	DailyTokens        int64 `mapstructure:"daily_tokens"`
+	MonthlyTokens      int64 `mapstructure:"monthly_tokens"`
+	PerUserDailyTokens int64 `mapstructure:"per_user_daily_tokens"`
+}
+
+// PricingConfig 是每百万 token 的价格，用于估算每次测试的花费。
+type PricingConfig struct {
+	PromptPerMillion     float64 `mapstructure:"prompt_per_million"`
+	CompletionPerMillion float64 `mapstructure:"completion_per_million"`
+}
+
+type UsageService struct {
+	repo    *repository.UsageRepository
+	budget  BudgetConfig
+	pricing PricingConfig
+	logger  *slog.Logger
+}
+
+func NewUsageService(repo *repository.UsageRepository, budget BudgetConfig, pricing PricingConfig, logger *slog.Logger) *UsageService {
+	return &UsageService{repo: repo, budget: budget, pricing: pricing, logger: logger.With("component", "usage")}
+}
+
+// Record 记录一次测试消耗的 AI 用量。
+func (s *UsageService) Record(userKey, paperID string, usage model.AIUsage) {
+	cost := float64(usage.PromptTokens)*s.pricing.PromptPerMillion/1e6 +
+		float64(usage.CompletionTokens)*s.pricing.CompletionPerMillion/1e6
+	record := repository.UsageRecord{
+		Time:    time.Now(),
+		UserKey: userKey,
+		PaperID: paperID,
+		UsageTotals: repository.UsageTotals{
+			Tests:            1,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+			Cost:             cost,
+		},
+	}
+	if err := s.repo.Record(record); err != nil {
+		s.logger.Error("记录AI用量失败", "error", err, "paper_id", paperID)
+	}
+}
+
+// BudgetExceeded 检查全局和用户预算，超出时返回原因。pending 为当前测试已消耗但尚未记录的 token 数，
+// 在每次调用AI前检查，避免一次测试超出整套试卷的用量。
+func (s *UsageService) BudgetExceeded(userKey string, pending int64) (bool, string) {
+	now := time.Now()
+	if s.budget.DailyTokens > 0 {
+		if used := s.repo.Day(now, "").TotalTokens + pending; used >= s.budget.DailyTokens {
+			return true, fmt.Sprintf("今日AI用量 %d 已达到每日预算 %d", used, s.budget.DailyTokens)
+		}
+	}
+	if s.budget.MonthlyTokens > 0 {
+		if used := s.repo.Month(now, "").TotalTokens + pending; used >= s.budget.MonthlyTokens {
+			return true, fmt.Sprintf("本月AI用量 %d 已达到每月预算 %d", used, s.budget.MonthlyTokens)
+		}
+	}
+	if s.budget.PerUserDailyTokens > 0 {
+		if used := s.repo.Day(now, userKey).TotalTokens + pending; used >= s.budget.PerUserDailyTokens {
+			return true, fmt.Sprintf("该用户今日AI用量 %d 已达到预算 %d", used, s.budget.PerUserDailyTokens)
+		}
+	}
+	return false, ""
+}
+
+type UsageReport struct {
+	Budget     BudgetConfig             `json:"budget"`
+	Today      repository.UsageTotals   `json:"today"`
+	ThisMonth  repository.UsageTotals   `json:"this_month"`
+	Days       []repository.DailyUsage  `json:"days"`
+	RecentRuns []repository.UsageRecord `json:"recent"`
+}
+
+// Report 汇总最近 days 天的用量，供管理接口展示。
+func (s *UsageService) Report(days int) *UsageReport {
+	now := time.Now()
+	return &UsageReport{
+		Budget:     s.budget,
+		Today:      s.repo.Day(now, ""),
+		ThisMonth:  s.repo.Month(now, ""),
+		Days:       s.repo.Days(now, days),
+		RecentRuns: s.repo.Recent(50),
+	}
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrNotFound 表示会话不存在、已过期或已注销。
+var ErrNotFound = errors.New("会话不存在或已过期")
+
+// Manager 签发不透明的会话 ID，并把它映射到学校平台的 X-Auth-Token。
+type Manager struct {
+	store  Store
+	ttl    time.Duration
+	logger *slog.Logger
+}
+
+func NewManager(store Store, ttl time.Duration, logger *slog.Logger) *Manager {
+	return &Manager{store: store, ttl: ttl, logger: logger.With("component", "session")}
+}
+
+// storeKey 返回会话 ID 的摘要，存储中只保存摘要。
+func storeKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Create 为登录成功的用户创建会话，同时清理已过期的会话。
+func (m *Manager) Create(username, upstreamToken string) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sess := Session{
+		Username:      username,
+		UpstreamToken: upstreamToken,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(m.ttl),
+	}
+	if removed, err := m.store.DeleteExpired(now); err != nil {
+		m.logger.Warn("清理过期会话失败", "error", err)
+	} else if removed > 0 {
+		m.logger.Info("已清理过期会话", "removed", removed)
+	}
+	if err := m.store.Save(storeKey(id), sess); err != nil {
+		return nil, err
+	}
+	sess.ID = id
+	m.logger.Info("会话已创建", "username", username, "expires_at", sess.ExpiresAt)
+	return &sess, nil
+}
+
+// Get 返回未过期的会话。
+func (m *Manager) Get(id string) (*Session, error) {
+	if id == "" {
+		return nil, ErrNotFound
+	}
+	sess, ok := m.store.Get(storeKey(id))
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if sess.Expired(time.Now()) {
+		if err := m.store.Delete(storeKey(id)); err != nil {
+			m.logger.Warn("删除过期会话失败", "error", err)
+		}
+		return nil, ErrNotFound
+	}
+	sess.ID = id
+	return &sess, nil
+}
+
+// Delete 注销会话并丢弃其中的上游令牌。会话不存在时不报错。
+func (m *Manager) Delete(id string) error {
+	return m.store.Delete(storeKey(id))
+}
+
+// Expire 在上游返回未授权 (令牌已失效) 时调用，删除会话以要求用户重新登录。
+func (m *Manager) Expire(sess *Session) {
+	if err := m.Delete(sess.ID); err != nil {
+		m.logger.Warn("删除失效会话失败", "username", sess.Username, "error", err)
+		return
+	}
+	m.logger.Info("上游令牌已失效，会话已删除", "username", sess.Username)
+}
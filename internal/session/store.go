@@ -0,0 +1,162 @@
+package session
+
+import (
+	"HDU-Auto-Word-Ans-Online-Backend/internal/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendFile   = "file"
+)
+
+// Config 控制会话的存储方式和有效期。
+type Config struct {
+	Backend  string `mapstructure:"backend"`   // memory | file
+	FilePath string `mapstructure:"file_path"` // backend 为 file 时的存储文件
+	TTLHours int    `mapstructure:"ttl_hours"`
+}
+
+// Session 是一次登录。UpstreamToken 为学校平台的 X-Auth-Token，只在服务端保存。
+type Session struct {
+	ID            string    `json:"-"`
+	Username      string    `json:"username"`
+	UpstreamToken string    `json:"upstream_token"`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+func (s *Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// Store 按会话 ID 的摘要保存会话，原始会话 ID 不会落盘。
+type Store interface {
+	Save(key string, s Session) error
+	Get(key string) (Session, bool)
+	Delete(key string) error
+	// DeleteExpired 删除所有已过期的会话，返回删除的数量。
+	DeleteExpired(now time.Time) (int, error)
+}
+
+// NewStore 按配置创建存储。backend 不区分大小写，与配置校验一致。
+func NewStore(cfg Config) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendFile:
+		return NewFileStore(cfg.FilePath)
+	default:
+		return nil, fmt.Errorf("未知的会话存储类型 %q", cfg.Backend)
+	}
+}
+
+// MemoryStore 只在内存中保存会话，服务重启后所有用户需要重新登录。
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]Session)}
+}
+
+func (s *MemoryStore) Save(key string, sess Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[key] = sess
+	return nil
+}
+
+func (s *MemoryStore) Get(key string) (Session, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sess, ok := s.sessions[key]
+	return sess, ok
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, key)
+	return nil
+}
+
+func (s *MemoryStore) DeleteExpired(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteExpiredLocked(now), nil
+}
+
+func (s *MemoryStore) deleteExpiredLocked(now time.Time) int {
+	removed := 0
+	for key, sess := range s.sessions {
+		if sess.Expired(now) {
+			delete(s.sessions, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// FileStore 在内存中保存会话，并在每次修改后整体写入 JSON 文件，使会话在重启后仍然有效。
+// 文件中包含上游令牌，权限为 0600。
+type FileStore struct {
+	MemoryStore
+	path string
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{MemoryStore: MemoryStore{sessions: make(map[string]Session)}, path: path}
+	byteValue, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(byteValue) > 0 {
+		if err := json.Unmarshal(byteValue, &s.sessions); err != nil {
+			return nil, fmt.Errorf("解析会话文件 '%s' 失败: %w", path, err)
+		}
+	}
+	s.deleteExpiredLocked(time.Now())
+	return s, nil
+}
+
+func (s *FileStore) persistLocked() error {
+	byteValue, err := json.MarshalIndent(s.sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(s.path, byteValue, 0600)
+}
+
+func (s *FileStore) Save(key string, sess Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[key] = sess
+	return s.persistLocked()
+}
+
+func (s *FileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[key]; !ok {
+		return nil
+	}
+	delete(s.sessions, key)
+	return s.persistLocked()
+}
+
+func (s *FileStore) DeleteExpired(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := s.deleteExpiredLocked(now)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.persistLocked()
+}
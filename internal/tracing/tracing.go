@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "HDU-Auto-Word-Ans-Online-Backend"
+
+// Config 对应配置文件中的 tracing 段。Exporter 为空或 none 时不导出任何 span。
+type Config struct {
+	Exporter    string  `mapstructure:"exporter"`     // none | stdout | otlp
+	Endpoint    string  `mapstructure:"endpoint"`     // OTLP/HTTP 地址，例如 localhost:4318；为空时使用 OTEL_EXPORTER_OTLP_* 环境变量
+	Insecure    bool    `mapstructure:"insecure"`     // OTLP 是否使用明文 HTTP
+	ServiceName string  `mapstructure:"service_name"` // 上报的 service.name
+	SampleRatio float64 `mapstructure:"sample_ratio"` // 根 span 采样率，<= 0 或 > 1 时按 1 处理
+}
+
+// Enabled 报告是否配置了导出器。
+func (c Config) Enabled() bool {
+	e := strings.ToLower(strings.TrimSpace(c.Exporter))
+	return e != "" && e != "none"
+}
+
+// Service 返回上报的服务名，未配置时使用默认值。
+func (c Config) Service() string {
+	if c.ServiceName == "" {
+		return "hdu-word-backend"
+	}
+	return c.ServiceName
+}
+
+// Setup 安装全局 TracerProvider 和 W3C 传播器，返回的函数用于在退出前刷新剩余的 span。
+// 未启用时保持 otel 默认的 no-op 实现。
+func Setup(ctx context.Context, cfg Config, logger *slog.Logger) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(strings.TrimSpace(cfg.Exporter)) {
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("未知的 tracing.exporter: %q (可选 none, stdout, otlp)", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("创建 span 导出器失败: %w", err)
+	}
+
+	serviceName := cfg.Service()
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("创建 tracing resource 失败: %w", err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	logger.Info("链路追踪已启用", "exporter", cfg.Exporter, "service_name", serviceName, "sample_ratio", ratio)
+	return provider.Shutdown, nil
+}
+
+// Start 用全局 TracerProvider 开启一个 span。
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartLinkedRoot 开启一个新的根 span 并链接到 ctx 中的 span，用于在请求结束后继续运行的后台任务。
+func StartLinkedRoot(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// End 结束 span，err 不为空时将 span 标记为失败。
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID 返回 ctx 中有效 span 的 trace ID，没有时返回空串。
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
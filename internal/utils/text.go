@@ -0,0 +1,10 @@
+package utils
+
+// IsEnglish 判断字符串是否以英文字母开头，用于区分 "英译中" 与 "中译英" 题目。
+func IsEnglish(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	firstChar := rune(s[0])
+	return (firstChar >= 'a' && firstChar <= 'z') || (firstChar >= 'A' && firstChar <= 'Z')
+}
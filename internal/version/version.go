@@ -0,0 +1,41 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// 以下变量在构建时通过 -ldflags "-X HDU-Auto-Word-Ans-Online-Backend/internal/version.Version=..." 注入。
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info 是对外报告的版本与构建信息。
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get 返回版本信息。未通过 ldflags 注入提交号时，尝试从 Go 工具链记录的 VCS 信息中读取。
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}